@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
+	"net/http"
 )
 
 func AdminAuth(c *fiber.Ctx) error {
@@ -16,3 +17,18 @@ func AdminAuth(c *fiber.Ctx) error {
 
 	return c.Next()
 }
+
+// PlatformAdminAuth restricts a route to the admins of the whole platform.
+// It guards what all organizations share, like the movie catalog, which the
+// admins of a single organization may not change.
+func PlatformAdminAuth(c *fiber.Ctx) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok || !user.IsAdmin {
+		return ErrUnauthorized()
+	}
+	if !user.IsPlatformAdmin() {
+		return NewError(http.StatusForbidden, "only platform admins can do this")
+	}
+
+	return c.Next()
+}
@@ -43,7 +43,7 @@ func (h *AuthHandler) HandleAuthenticate(c *fiber.Ctx) error {
 		return ErrBadRequest()
 	}
 
-	user, err := h.userStore.GetUserByEmail(c.Context(), params.Email)
+	user, err := h.userStore.GetUserByEmail(c.Context(), types.NormalizeEmail(params.Email))
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			fmt.Println("Error getting user by email:", err)
@@ -65,6 +65,30 @@ func (h *AuthHandler) HandleAuthenticate(c *fiber.Ctx) error {
 	return c.JSON(resp)
 }
 
+// HandleRegister signs up a new customer without a token and logs them in.
+func (h *AuthHandler) HandleRegister(c *fiber.Ctx) error {
+	var params types.CreateUserParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	user, err := createUser(c.Context(), h.userStore, params)
+	if err != nil {
+		return err
+	}
+
+	resp := AuthResponse{
+		User:  user,
+		Token: CreateTokenFromUser(user),
+	}
+
+	return c.JSON(resp)
+}
+
 func CreateTokenFromUser(user *types.User) string {
 	now := time.Now()
 	expires := now.Add(time.Hour * 2).Unix()
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
 	"net/http"
 	"net/http/httptest"
@@ -93,3 +94,83 @@ func TestAuthenticateSuccess(t *testing.T) {
 		t.Fatalf("expected the user to be the inserted user")
 	}
 }
+
+func TestRegister(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+	fixtures.AddUser(tdb.Store, "james", "evergreen", false)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	authHandler := NewAuthHandler(tdb.User)
+	app.Post("/register", authHandler.HandleRegister)
+	app.Post("/auth", authHandler.HandleAuthenticate)
+
+	register := func(params types.CreateUserParams) *http.Response {
+		b, _ := json.Marshal(params)
+		req := httptest.NewRequest("POST", "/register", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	resp := register(types.CreateUserParams{FirstName: "jo", LastName: "doe", Email: "not an email", Password: "short"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d for invalid params, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	var errs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&errs); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs["email"]) == 0 || len(errs["password"]) == 0 {
+		t.Fatalf("expected email and password errors, got %v", errs)
+	}
+
+	resp = register(types.CreateUserParams{FirstName: "james", LastName: "evergreen", Email: "james@evergreen.com", Password: "james_evergreen"})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d for a registered email, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	resp = register(types.CreateUserParams{FirstName: "james", LastName: "evergreen", Email: " James@Evergreen.com ", Password: "james_evergreen"})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d for a registered email in another casing, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	resp = register(types.CreateUserParams{FirstName: "john", LastName: "doe", Email: "John@Doe.com", Password: "password12345"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var authResp AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		t.Fatal(err)
+	}
+	if authResp.User.ID.IsZero() || authResp.User.IsAdmin || authResp.User.Email != "john@doe.com" {
+		t.Fatalf("expected a new customer with a lowercase email, got %+v", authResp.User)
+	}
+
+	claims, err := validateToken(authResp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["id"] != authResp.User.ID.Hex() {
+		t.Fatalf("expected a token of the new user, got %v", claims["id"])
+	}
+
+	b, _ := json.Marshal(AuthParams{Email: "JOHN@doe.com ", Password: "password12345"})
+	req := httptest.NewRequest("POST", "/auth", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected logging in with another casing to succeed, got status code %d", resp.StatusCode)
+	}
+}
@@ -1,8 +1,16 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"time"
 )
 
 type BookingHandler struct {
@@ -80,9 +88,217 @@ func (h *BookingHandler) HandleCancelBooking(c *fiber.Ctx) error {
 		return ErrUnauthorized()
 	}
 
-	if err := h.store.Booking.UpdateBooking(c.Context(), id, db.Map{"canceled": true}); err != nil {
+	// the movie has been seen, there is nothing left to give back
+	if time.Now().After(booking.Date) {
+		return NewError(http.StatusBadRequest, "past bookings can not be canceled")
+	}
+
+	if err := cancelBooking(c.Context(), h.store, booking); err != nil {
 		return err
 	}
 
 	return c.JSON(map[string]string{"message": "success"})
 }
+
+// HandleCapturePayment records that the payment gateway collected the part of
+// a booking that was left to it. Only captured payments are refunded when the
+// booking is canceled.
+func (h *BookingHandler) HandleCapturePayment(c *fiber.Ctx) error {
+	var params types.CapturePaymentParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	id := c.Params("id")
+	if _, err := h.store.Booking.GetBookingByID(c.Context(), id); err != nil {
+		return ErrResourceNotFound("booking")
+	}
+
+	if err := h.store.Booking.CapturePayment(c.Context(), id, params.Reference); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusConflict, "booking has no pending payment to capture")
+		}
+
+		return err
+	}
+
+	booking, err := h.store.Booking.GetBookingByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	var captured []types.Payment
+	for _, payment := range booking.Payments {
+		if payment.Method == types.PaymentGateway && payment.Reference == params.Reference {
+			captured = append(captured, payment)
+		}
+	}
+	if err := accrueLoyaltyPoints(c.Context(), h.store, booking, captured); err != nil {
+		fmt.Println("Error accruing loyalty points:", err)
+	}
+
+	return c.JSON(booking)
+}
+
+// cancelBooking cancels the booking and undoes everything that came with it:
+// payments are refunded, loyalty points reversed and add-ons put back into
+// stock.
+func cancelBooking(ctx context.Context, store *db.Store, booking *types.Booking) error {
+	if err := store.Booking.CancelBooking(ctx, booking.ID.Hex()); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusBadRequest, "booking is already canceled")
+		}
+
+		return err
+	}
+
+	// payments can not be captured anymore, refund what was captured until now
+	booking, err := store.Booking.GetBookingByID(ctx, booking.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if err := refundBooking(ctx, store, booking); err != nil {
+		return err
+	}
+
+	if err := reverseLoyaltyPoints(ctx, store, booking); err != nil {
+		return err
+	}
+
+	releaseAddOns(ctx, store, booking.AddOns)
+
+	return nil
+}
+
+func (h *BookingHandler) HandleGetInvoice(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	booking, err := h.store.Booking.GetBookingByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("booking")
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return ErrUnauthorized()
+	}
+
+	if booking.UserID != user.ID && !user.IsAdmin {
+		return ErrUnauthorized()
+	}
+
+	invoice, err := h.store.Invoice.GetInvoiceByBookingID(c.Context(), booking.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		invoice, err = h.issueInvoice(c.Context(), booking)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.Query("format") == "pdf" || c.Accepts(fiber.MIMEApplicationJSON, "application/pdf") == "application/pdf" {
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", invoice.Number+".pdf"))
+		return c.Send(renderInvoicePDF(invoice))
+	}
+
+	return c.JSON(invoice)
+}
+
+// issueInvoice creates the invoice of a booking the first time it is
+// requested, so that invoice numbers are only consumed by bookings that
+// actually get invoiced.
+func (h *BookingHandler) issueInvoice(ctx context.Context, booking *types.Booking) (*types.Invoice, error) {
+	if booking.Canceled {
+		return nil, NewError(http.StatusBadRequest, "canceled bookings can not be invoiced")
+	}
+
+	hall, err := h.store.Hall.GetHallByID(ctx, booking.HallID)
+	if err != nil {
+		return nil, ErrResourceNotFound("hall")
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(ctx, hall.Cinema.Hex())
+	if err != nil {
+		return nil, ErrResourceNotFound("cinema")
+	}
+
+	customer, err := h.store.User.GetUserByID(ctx, booking.UserID.Hex())
+	if err != nil {
+		return nil, ErrResourceNotFound("user")
+	}
+
+	// bookings made before prices were recorded are invoiced at the current price
+	price := booking.Price
+	if price == nil {
+		if price, err = priceBooking(ctx, h.store, hall, booking.AddOns); err != nil {
+			return nil, err
+		}
+	}
+
+	address := cinema.Location
+	if cinema.Address != nil {
+		address = cinema.Address.String()
+	}
+
+	invoice := &types.Invoice{
+		BookingID: booking.ID,
+		UserID:    booking.UserID,
+		CinemaID:  cinema.ID,
+		IssuedAt:  time.Now().UTC(),
+		Seller: types.InvoiceParty{
+			Name:    cinema.Name,
+			Address: address,
+			Country: cinema.Country,
+		},
+		Customer: types.InvoiceParty{
+			Name:  fmt.Sprintf("%s %s", customer.FirstName, customer.LastName),
+			Email: customer.Email,
+		},
+		Price: *price,
+	}
+
+	return h.store.Invoice.InsertInvoice(ctx, invoice)
+}
+
+// cancelUpcomingBookings cancels the future bookings of all halls matching
+// the filter.
+func cancelUpcomingBookings(ctx context.Context, store *db.Store, hallFilter db.Map) (int, error) {
+	halls, err := store.Hall.GetHalls(ctx, hallFilter, nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(halls) == 0 {
+		return 0, nil
+	}
+
+	hallIDs := make([]primitive.ObjectID, len(halls))
+	for i, hall := range halls {
+		hallIDs[i] = hall.ID
+	}
+
+	filter := db.Map{
+		"hallID":   db.Map{"$in": hallIDs},
+		"canceled": false,
+		"date":     db.Map{"$gte": time.Now()},
+	}
+	bookings, err := store.Booking.GetBookings(ctx, filter, &db.Pagination{Page: 1})
+	if err != nil {
+		return 0, err
+	}
+
+	canceled := 0
+	for _, booking := range bookings {
+		if err := cancelBooking(ctx, store, booking); err != nil {
+			fmt.Printf("Error canceling booking %s: %s\n", booking.ID.Hex(), err)
+			continue
+		}
+		canceled++
+	}
+
+	return canceled, nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
@@ -9,6 +10,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -20,7 +23,7 @@ func TestUserGetBooking(t *testing.T) {
 	var (
 		nonAuthUser    = fixtures.AddUser(db.Store, "heron", "preston", false)
 		user           = fixtures.AddUser(db.Store, "heron", "preston", false)
-		cinema         = fixtures.AddCinema(db.Store, "babylon", "berlin", 4, nil)
+		cinema         = fixtures.AddCinema(db.Store, "babylon", "berlin", nil)
 		movie          = fixtures.AddMovie(db.Store, "the lighthouse", types.Horror)
 		hall           = fixtures.AddHall(db.Store, 100, 10.0, cinema.ID, movie.ID)
 		booking        = fixtures.AddBooking(db.Store, user.ID, hall.ID, types.Morning, time.Now().AddDate(0, 0, 1))
@@ -70,7 +73,7 @@ func TestAdminGetBookings(t *testing.T) {
 	var (
 		adminUser      = fixtures.AddUser(db.Store, "admin", "admin", true)
 		user           = fixtures.AddUser(db.Store, "heron", "preston", false)
-		cinema         = fixtures.AddCinema(db.Store, "babylon", "berlin", 4, nil)
+		cinema         = fixtures.AddCinema(db.Store, "babylon", "berlin", nil)
 		movie          = fixtures.AddMovie(db.Store, "the lighthouse", types.Horror)
 		hall           = fixtures.AddHall(db.Store, 100, 10.0, cinema.ID, movie.ID)
 		booking        = fixtures.AddBooking(db.Store, user.ID, hall.ID, types.Morning, time.Now().AddDate(0, 0, 1))
@@ -143,3 +146,160 @@ func TestAdminGetBookings(t *testing.T) {
 		t.Fatal("expected status code is 401")
 	}
 }
+
+func TestGetBookingInvoice(t *testing.T) {
+	db := setup(t)
+	defer db.tearDown(t)
+
+	var (
+		user           = fixtures.AddUser(db.Store, "heron", "preston", false)
+		otherUser      = fixtures.AddUser(db.Store, "james", "evergreen", false)
+		cinema         = fixtures.AddCinema(db.Store, "babylon", "berlin", nil)
+		_              = fixtures.AddTaxRate(db.Store, "MwSt. 7%", "", cinema.ID, types.ProductTicket, 7)
+		movie          = fixtures.AddMovie(db.Store, "the lighthouse", types.Horror)
+		hall           = fixtures.AddHall(db.Store, 100, 10.70, cinema.ID, movie.ID)
+		booking        = fixtures.AddBooking(db.Store, user.ID, hall.ID, types.Morning, time.Now().AddDate(0, 0, 1))
+		app            = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		route          = app.Group("/", JWTAuthentication(db.User))
+		bookingHandler = NewBookingHandler(db.Store)
+	)
+
+	route.Get("/:id/invoice", bookingHandler.HandleGetInvoice)
+
+	req := httptest.NewRequest("GET", "/"+booking.ID.Hex()+"/invoice", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var invoice types.Invoice
+	if err := json.NewDecoder(resp.Body).Decode(&invoice); err != nil {
+		t.Fatal(err)
+	}
+	if len(invoice.Number) == 0 {
+		t.Fatal("expected the invoice to be numbered")
+	}
+	if invoice.Price.Gross != 10.70 || invoice.Price.Net != 10.00 || invoice.Price.Tax != 0.70 {
+		t.Fatalf("expected net 10.00, tax 0.70 and gross 10.70, got %+v", invoice.Price)
+	}
+
+	// requesting the invoice again must not issue a new number
+	req = httptest.NewRequest("GET", "/"+booking.ID.Hex()+"/invoice?format=pdf", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("expected a PDF, got %s", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), invoice.Number) {
+		t.Fatalf("expected the PDF to be named after invoice %s", invoice.Number)
+	}
+
+	// other users can not access the invoice
+	req = httptest.NewRequest("GET", "/"+booking.ID.Hex()+"/invoice", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(otherUser))
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	// concurrent requests for the invoice of another booking share the next
+	// number without skipping any
+	var (
+		next    = fixtures.AddBooking(db.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, 1))
+		numbers = make([]string, 5)
+		wg      sync.WaitGroup
+	)
+	for i := range numbers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := httptest.NewRequest("GET", "/"+next.ID.Hex()+"/invoice", nil)
+			req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			var invoice types.Invoice
+			if err := json.NewDecoder(resp.Body).Decode(&invoice); err != nil {
+				t.Error(err)
+				return
+			}
+			numbers[i] = invoice.Number
+		}(i)
+	}
+	wg.Wait()
+
+	if !strings.HasSuffix(invoice.Number, "-000001") {
+		t.Fatalf("expected the first invoice to be number 1, got %s", invoice.Number)
+	}
+	for _, number := range numbers {
+		if number != strings.TrimSuffix(invoice.Number, "1")+"2" {
+			t.Fatalf("expected every request to return invoice number 2, got %v", numbers)
+		}
+	}
+}
+
+func TestCancelPastBooking(t *testing.T) {
+	db := setup(t)
+	defer db.tearDown(t)
+
+	var (
+		user           = fixtures.AddUser(db.Store, "heron", "preston", false)
+		cinema         = fixtures.AddCinema(db.Store, "babylon", "berlin", nil)
+		movie          = fixtures.AddMovie(db.Store, "the lighthouse", types.Horror)
+		hall           = fixtures.AddHall(db.Store, 100, 10.0, cinema.ID, movie.ID)
+		app            = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		route          = app.Group("/", JWTAuthentication(db.User))
+		bookingHandler = NewBookingHandler(db.Store)
+	)
+
+	route.Get("/:id/cancel", bookingHandler.HandleCancelBooking)
+
+	booking, err := db.Booking.InsertBooking(context.TODO(), &types.Booking{
+		UserID:   user.ID,
+		HallID:   hall.ID,
+		Session:  types.Evening,
+		Date:     time.Now().AddDate(0, 0, -1),
+		Price:    &types.PriceBreakdown{Currency: types.DefaultCurrency, Gross: 10.0},
+		Payments: []types.Payment{{Method: types.PaymentGateway, Amount: 10.0, Status: types.PaymentCaptured}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/"+booking.ID.Hex()+"/cancel", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	balances, err := db.Wallet.GetWalletBalances(context.TODO(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[types.DefaultCurrency] != 0 {
+		t.Fatalf("expected no refund for a past booking, got a wallet balance of %.2f", balances[types.DefaultCurrency])
+	}
+}
@@ -1,18 +1,34 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultNearRadius = 10
+	maxNearRadius     = 500
 )
 
 type CinemaHandler struct {
 	store *db.Store
+	blobs blob.Store
 }
 
-func NewCinemaHandler(store *db.Store) *CinemaHandler {
+func NewCinemaHandler(store *db.Store, blobs blob.Store) *CinemaHandler {
 	return &CinemaHandler{
 		store: store,
+		blobs: blobs,
 	}
 }
 
@@ -24,12 +40,54 @@ func (h *CinemaHandler) HandleGetCinema(c *fiber.Ctx) error {
 		return ErrResourceNotFound("cinema")
 	}
 
+	localize(c, cinema)
+
 	return c.JSON(cinema)
 }
 
 type CinemaQueryParams struct {
 	db.Pagination
-	Rating int
+	// MinRating is the lowest average customer rating cinemas should have.
+	MinRating float64
+	Retired   bool
+	// Amenity is a comma separated list of amenities cinemas need to have
+	// all of.
+	Amenity string
+	// Near is a position as "lat,lng" to find the closest cinemas to.
+	Near string
+	// Radius is the distance from Near in kilometers.
+	Radius float64
+}
+
+// near parses the position and radius in meters of a "near me" search.
+func (p CinemaQueryParams) near() (types.Coordinates, float64, error) {
+	var near types.Coordinates
+
+	parts := strings.Split(p.Near, ",")
+	if len(parts) != 2 {
+		return near, 0, NewError(http.StatusBadRequest, "near should be a position like 52.52,13.40")
+	}
+
+	var err error
+	if near.Lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err != nil {
+		return near, 0, NewError(http.StatusBadRequest, "near should be a position like 52.52,13.40")
+	}
+	if near.Lng, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+		return near, 0, NewError(http.StatusBadRequest, "near should be a position like 52.52,13.40")
+	}
+	if !near.IsValid() {
+		return near, 0, NewError(http.StatusBadRequest, "near should be a valid latitude and longitude")
+	}
+
+	radius := p.Radius
+	if radius <= 0 {
+		radius = defaultNearRadius
+	}
+	if radius > maxNearRadius {
+		return near, 0, NewError(http.StatusBadRequest, fmt.Sprintf("radius should be at most %d km", maxNearRadius))
+	}
+
+	return near, radius * 1000, nil
 }
 
 func (h *CinemaHandler) HandleGetCinemas(c *fiber.Ctx) error {
@@ -39,13 +97,57 @@ func (h *CinemaHandler) HandleGetCinemas(c *fiber.Ctx) error {
 	}
 
 	filter := db.Map{
-		"rating": params.Rating,
+		"retired": db.Map{"$ne": true},
+	}
+	if params.MinRating < 0 || params.MinRating > 5 {
+		return NewError(http.StatusBadRequest, "minRating should be between 0 and 5")
+	}
+	if params.MinRating > 0 {
+		filter["rating.average"] = db.Map{"$gte": params.MinRating}
 	}
+	if params.Retired {
+		filter["retired"] = true
+	}
+	if len(params.Amenity) > 0 {
+		tags := []types.Amenity{}
+		for _, tag := range strings.Split(params.Amenity, ",") {
+			amenity := types.Amenity(strings.ToLower(strings.TrimSpace(tag)))
+			if !amenity.IsValid() {
+				return NewError(http.StatusBadRequest, fmt.Sprintf("unknown amenity %q", tag))
+			}
+			tags = append(tags, amenity)
+		}
+		filter["amenities"] = db.Map{"$all": tags}
+	}
+
+	if len(params.Near) > 0 {
+		near, radius, err := params.near()
+		if err != nil {
+			return err
+		}
+
+		cinemas, err := h.store.Cinema.GetCinemasNear(c.Context(), near, radius, filter, &params.Pagination)
+		if err != nil {
+			return err
+		}
+
+		localize(c, cinemas...)
+
+		resp := ResourceResponse{
+			Results: len(cinemas),
+			Data:    cinemas,
+			Page:    int(params.Page),
+		}
+		return c.JSON(resp)
+	}
+
 	cinemas, err := h.store.Cinema.GetCinemas(c.Context(), filter, &params.Pagination)
 	if err != nil {
 		return ErrResourceNotFound("cinema")
 	}
 
+	localize(c, cinemas...)
+
 	resp := ResourceResponse{
 		Results: len(cinemas),
 		Data:    cinemas,
@@ -62,10 +164,127 @@ func (h *CinemaHandler) HandleGetHalls(c *fiber.Ctx) error {
 	}
 
 	filter := db.Map{"cinema": objID}
-	halls, err := h.store.Hall.GetHalls(c.Context(), filter)
+	if feature := c.Query("feature"); len(feature) > 0 {
+		features, err := parseFeatures(feature)
+		if err != nil {
+			return err
+		}
+		filter["features"] = db.Map{"$all": features}
+	}
+
+	halls, err := h.store.Hall.GetHalls(c.Context(), filter, nil)
 	if err != nil {
 		return ErrResourceNotFound("hall")
 	}
 
 	return c.JSON(halls)
 }
+
+func (h *CinemaHandler) HandlePostCinema(c *fiber.Ctx) error {
+	var params types.CreateCinemaParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	cinema := types.NewCinemaFromParams(params)
+	if orgID, ok := db.TenantFromContext(c.Context()); ok {
+		cinema.OrganizationID = orgID
+	} else if !cinema.OrganizationID.IsZero() {
+		if _, err := h.store.Organization.GetOrganizationByID(c.Context(), cinema.OrganizationID); err != nil {
+			return ErrResourceNotFound("organization")
+		}
+	}
+
+	cinema, err := h.store.Cinema.InsertCinema(c.Context(), cinema)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cinema)
+}
+
+func (h *CinemaHandler) HandlePutCinema(c *fiber.Ctx) error {
+	var (
+		params types.UpdateCinemaParams
+		id     = c.Params("id")
+	)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	update := db.Map(params.ToBSON())
+	if len(update) == 0 {
+		return NewError(http.StatusBadRequest, "nothing to update")
+	}
+
+	if _, err := h.store.Cinema.GetCinemaByID(c.Context(), id); err != nil {
+		return ErrResourceNotFound("cinema")
+	}
+
+	if err := h.store.Cinema.UpdateCinema(c.Context(), db.Map{"_id": objID}, db.Map{"$set": update}); err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]string{"updated": id})
+}
+
+// HandleRetireCinema closes a cinema for good. The cinema and its halls are
+// kept for the booking history, but all upcoming bookings are canceled and
+// refunded and its photos are deleted.
+func (h *CinemaHandler) HandleRetireCinema(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("cinema")
+		}
+
+		return err
+	}
+
+	if cinema.Retired {
+		return NewError(http.StatusBadRequest, "cinema is already retired")
+	}
+
+	update := db.Map{
+		"$set":   db.Map{"retired": true, "retiredAt": time.Now().UTC()},
+		"$unset": db.Map{"photos": ""},
+	}
+	if err := h.store.Cinema.UpdateCinema(c.Context(), db.Map{"_id": cinema.ID}, update); err != nil {
+		return err
+	}
+
+	for _, photo := range cinema.Photos {
+		deleteImage(c.Context(), h.blobs, photo)
+	}
+
+	canceled, err := cancelUpcomingBookings(c.Context(), h.store, db.Map{"cinema": cinema.ID})
+	if err != nil {
+		return err
+	}
+
+	deleted, err := clearProgramme(c.Context(), h.store, db.Map{"cinemaID": cinema.ID})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]any{"retired": id, "canceledBookings": canceled, "deletedShowtimes": deleted})
+}
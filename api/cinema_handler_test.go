@@ -0,0 +1,250 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAdminRetireCinema(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser     = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		user          = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		app           = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin         = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		cinemaHandler = NewCinemaHandler(tdb.Store, tdb.blobs)
+		token         = CreateTokenFromUser(adminUser)
+	)
+
+	admin.Post("/cinema", cinemaHandler.HandlePostCinema)
+	admin.Put("/cinema/:id", cinemaHandler.HandlePutCinema)
+	admin.Delete("/cinema/:id", cinemaHandler.HandleRetireCinema)
+
+	params := types.CreateCinemaParams{Name: "babylon", Location: "berlin", Country: "deu"}
+	b, _ := json.Marshal(params)
+	req := httptest.NewRequest("POST", "/cinema", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", token)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d for a three letter country, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	params.Country = "de"
+	b, _ = json.Marshal(params)
+	req = httptest.NewRequest("POST", "/cinema", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", token)
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cinema types.Cinema
+	if err := json.NewDecoder(resp.Body).Decode(&cinema); err != nil {
+		t.Fatal(err)
+	}
+	if cinema.Country != "DE" || cinema.Currency != types.DefaultCurrency {
+		t.Fatalf("expected country DE and currency %s, got %s and %s", types.DefaultCurrency, cinema.Country, cinema.Currency)
+	}
+
+	// moving the cinema moves its location along
+	update := types.UpdateCinemaParams{Address: &types.Address{Street: "Rosa-Luxemburg-Str. 30", PostalCode: "10178", City: "Berlin"}}
+	b, _ = json.Marshal(update)
+	req = httptest.NewRequest("PUT", "/cinema/"+cinema.ID.Hex(), bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", token)
+
+	if resp, err = app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	moved, err := tdb.Cinema.GetCinemaByID(context.TODO(), cinema.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Location != "Rosa-Luxemburg-Str. 30, 10178 Berlin" {
+		t.Fatalf("expected the location to follow the address, got %q", moved.Location)
+	}
+
+	var (
+		movie   = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall    = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		booking = fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, 3))
+	)
+
+	startsAt := time.Now().AddDate(0, 0, 3)
+	showtime := &types.Showtime{MovieID: movie.ID, HallID: hall.ID, CinemaID: cinema.ID, StartsAt: startsAt, EndsAt: startsAt.Add(2 * time.Hour)}
+	if err := tdb.Showtime.InsertShowtimes(context.TODO(), []*types.Showtime{showtime}); err != nil {
+		t.Fatal(err)
+	}
+
+	req = httptest.NewRequest("DELETE", "/cinema/"+cinema.ID.Hex(), nil)
+	req.Header.Add("X-Api-Token", token)
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	canceled, err := tdb.Booking.GetBookingByID(context.TODO(), booking.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !canceled.Canceled {
+		t.Fatal("expected the upcoming booking to be canceled")
+	}
+
+	showtimes, err := tdb.Showtime.CountShowtimes(context.TODO(), db.Map{"cinemaID": cinema.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if showtimes != 0 {
+		t.Fatalf("expected the upcoming showtimes to be deleted, got %d", showtimes)
+	}
+
+	retired, err := tdb.Cinema.GetCinemaByID(context.TODO(), cinema.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !retired.Retired {
+		t.Fatal("expected the cinema to be retired")
+	}
+}
+
+func TestGetCinemasNear(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user          = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		app           = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1         = app.Group("/", JWTAuthentication(tdb.User))
+		cinemaHandler = NewCinemaHandler(tdb.Store, tdb.blobs)
+	)
+
+	apiV1.Get("/cinema", cinemaHandler.HandleGetCinemas)
+
+	positions := map[string]types.Coordinates{
+		"zoo palast": {Lat: 52.5058, Lng: 13.3376},
+		"babylon":    {Lat: 52.5259, Lng: 13.4117},
+		"abaton":     {Lat: 53.5664, Lng: 9.9856},
+	}
+	for name, position := range positions {
+		params := types.CreateCinemaParams{Name: name, Location: "somewhere", Coordinates: &position}
+		if _, err := tdb.Cinema.InsertCinema(context.TODO(), types.NewCinemaFromParams(params)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// alexanderplatz, babylon is about 700m away and zoo palast about 5km
+	req := httptest.NewRequest("GET", "/cinema?near=52.5219,13.4132&radius=20", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var cinemas []types.NearbyCinema
+	if err := json.NewDecoder(resp.Body).Decode(&ResourceResponse{Data: &cinemas}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cinemas) != 2 {
+		t.Fatalf("expected the 2 cinemas in berlin, got %d", len(cinemas))
+	}
+	if cinemas[0].Name != "babylon" || cinemas[1].Name != "zoo palast" {
+		t.Fatalf("expected babylon before zoo palast, got %s and %s", cinemas[0].Name, cinemas[1].Name)
+	}
+	if cinemas[0].Distance > 1000 {
+		t.Fatalf("expected babylon to be less than 1km away, got %.0fm", cinemas[0].Distance)
+	}
+}
+
+func TestGetCinemasByAmenity(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user          = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		app           = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1         = app.Group("/", JWTAuthentication(tdb.User))
+		cinemaHandler = NewCinemaHandler(tdb.Store, tdb.blobs)
+	)
+
+	apiV1.Get("/cinema", cinemaHandler.HandleGetCinemas)
+
+	cinemas := []types.CreateCinemaParams{
+		{
+			Name:      "babylon",
+			Location:  "berlin",
+			Amenities: []types.Amenity{types.AmenityBar, types.AmenityWheelchairAccess},
+			OpeningHours: []types.OpeningHours{
+				{Day: "Friday", Open: "14:00", Close: "02:00"},
+			},
+			Contact: &types.Contact{Phone: "+49 30 2425969", Website: "https://babylonberlin.eu"},
+		},
+		{
+			Name:      "delphi",
+			Location:  "berlin",
+			Amenities: []types.Amenity{types.AmenityBar},
+		},
+	}
+	for _, params := range cinemas {
+		if errs := params.Validate(); len(errs) > 0 {
+			t.Fatalf("expected valid params, got %v", errs)
+		}
+		if _, err := tdb.Cinema.InsertCinema(context.TODO(), types.NewCinemaFromParams(params)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/cinema?amenity=bar,wheelchair-access", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var found []types.Cinema
+	if err := json.NewDecoder(resp.Body).Decode(&ResourceResponse{Data: &found}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 1 || found[0].Name != "babylon" {
+		t.Fatalf("expected only babylon, got %+v", found)
+	}
+	if len(found[0].OpeningHours) != 1 || found[0].OpeningHours[0].Day != "friday" {
+		t.Fatalf("expected the opening hours to be normalized, got %+v", found[0].OpeningHours)
+	}
+}
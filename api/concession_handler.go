@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"time"
+)
+
+type ConcessionHandler struct {
+	store *db.Store
+}
+
+func NewConcessionHandler(store *db.Store) *ConcessionHandler {
+	return &ConcessionHandler{
+		store: store,
+	}
+}
+
+func (h *ConcessionHandler) HandleGetConcessions(c *fiber.Ctx) error {
+	cinemaID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	filter := db.Map{"cinemaID": cinemaID, "active": true}
+	concessions, err := h.store.Concession.GetConcessions(c.Context(), filter)
+	if err != nil {
+		return ErrResourceNotFound("concession")
+	}
+
+	return c.JSON(concessions)
+}
+
+func (h *ConcessionHandler) HandlePostConcession(c *fiber.Ctx) error {
+	var params types.CreateConcessionParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(c.Context(), params.CinemaID)
+	if err != nil {
+		return ErrResourceNotFound("cinema")
+	}
+
+	concession := types.NewConcessionFromParams(params)
+	concession.OrganizationID = cinema.OrganizationID
+
+	concession, err = h.store.Concession.InsertConcession(c.Context(), concession)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(concession)
+}
+
+func (h *ConcessionHandler) HandlePutConcession(c *fiber.Ctx) error {
+	var (
+		params types.UpdateConcessionParams
+		id     = c.Params("id")
+	)
+
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	update := db.Map(params.ToBSON())
+	if len(update) == 0 {
+		return NewError(http.StatusBadRequest, "nothing to update")
+	}
+
+	if err := h.store.Concession.UpdateConcession(c.Context(), id, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("concession")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"updated": id})
+}
+
+type PickupParams struct {
+	// ConcessionIDs limits the pickup to some of the add-ons, all are
+	// collected if it is empty.
+	ConcessionIDs []string `json:"concessionIDs"`
+}
+
+// HandlePickup is used by staff at the counter to hand out pre-ordered
+// add-ons.
+func (h *ConcessionHandler) HandlePickup(c *fiber.Ctx) error {
+	var (
+		params PickupParams
+		id     = c.Params("id")
+	)
+
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	booking, err := h.store.Booking.GetBookingByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("booking")
+	}
+
+	if booking.Canceled {
+		return NewError(http.StatusBadRequest, "booking is canceled")
+	}
+
+	selected := map[string]bool{}
+	for _, concessionID := range params.ConcessionIDs {
+		selected[concessionID] = true
+	}
+
+	// each add-on is claimed on its own, one handed out at another counter in
+	// the meantime is skipped
+	now := time.Now().UTC()
+	collected := 0
+	for _, addOn := range booking.AddOns {
+		if addOn.Collected || (len(selected) > 0 && !selected[addOn.ConcessionID.Hex()]) {
+			continue
+		}
+
+		err := h.store.Booking.CollectAddOn(c.Context(), id, addOn.ConcessionID, now)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		collected++
+	}
+
+	if collected == 0 {
+		return NewError(http.StatusBadRequest, "there are no add-ons left to collect")
+	}
+
+	if booking, err = h.store.Booking.GetBookingByID(c.Context(), id); err != nil {
+		return err
+	}
+
+	return c.JSON(booking.AddOns)
+}
+
+// resolveAddOns looks up the ordered concessions in the catalog of the hall's
+// cinema and fixes their current price on the booking.
+func resolveAddOns(ctx context.Context, store *db.Store, hall *types.Hall, params []types.AddOnParams) ([]types.AddOn, error) {
+	addOns := []types.AddOn{}
+	for _, p := range params {
+		if errs := p.Validate(); len(errs) > 0 {
+			return nil, NewError(http.StatusBadRequest, fmt.Sprintf("invalid add-on %q", p.ConcessionID))
+		}
+
+		concession, err := store.Concession.GetConcessionByID(ctx, p.ConcessionID)
+		if err != nil || !concession.Active || concession.CinemaID != hall.Cinema {
+			return nil, ErrResourceNotFound("concession")
+		}
+
+		if concession.Stock < p.Quantity {
+			return nil, NewError(http.StatusBadRequest, fmt.Sprintf("%s is out of stock", concession.Name))
+		}
+
+		addOns = append(addOns, types.AddOn{
+			ConcessionID: concession.ID,
+			Name:         concession.Name,
+			Quantity:     p.Quantity,
+			UnitPrice:    concession.Price,
+		})
+	}
+
+	return addOns, nil
+}
+
+// reserveAddOns takes the ordered items out of stock. Either all items are
+// reserved or none.
+func reserveAddOns(ctx context.Context, store *db.Store, addOns []types.AddOn) error {
+	for i, addOn := range addOns {
+		err := store.Concession.ReserveStock(ctx, addOn.ConcessionID, addOn.Quantity)
+		if err == nil {
+			continue
+		}
+
+		releaseAddOns(ctx, store, addOns[:i])
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusBadRequest, fmt.Sprintf("%s is out of stock", addOn.Name))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// releaseAddOns puts add-ons that have not been collected back into stock.
+func releaseAddOns(ctx context.Context, store *db.Store, addOns []types.AddOn) {
+	for _, addOn := range addOns {
+		if addOn.Collected {
+			continue
+		}
+
+		if err := store.Concession.ReleaseStock(ctx, addOn.ConcessionID, addOn.Quantity); err != nil {
+			fmt.Println("Error releasing concession stock:", err)
+		}
+	}
+}
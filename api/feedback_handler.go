@@ -0,0 +1,115 @@
+package api
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"time"
+)
+
+type FeedbackHandler struct {
+	store *db.Store
+}
+
+func NewFeedbackHandler(store *db.Store) *FeedbackHandler {
+	return &FeedbackHandler{
+		store: store,
+	}
+}
+
+func (h *FeedbackHandler) HandleGetFeedback(c *fiber.Ctx) error {
+	var params db.ListOptions
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	cinemaID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := checkSort(params.Sort, "createdAt", "stars"); err != nil {
+		return err
+	}
+	if len(params.Sort) == 0 {
+		params.Sort = "-createdAt"
+	}
+
+	filter := db.Map{"cinemaID": cinemaID}
+	feedback, err := h.store.Feedback.GetFeedback(c.Context(), filter, &params)
+	if err != nil {
+		return err
+	}
+
+	total, err := h.store.Feedback.CountFeedback(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	resp := ResourceResponse{
+		Results: len(feedback),
+		Data:    feedback,
+		Page:    int(params.Page),
+		Total:   total,
+	}
+	return c.JSON(resp)
+}
+
+// HandlePostFeedback lets users rate the cinema of a booking once they have
+// been there. Every booking can be rated once, and each rating is added to
+// the rating of the cinema right away.
+func (h *FeedbackHandler) HandlePostFeedback(c *fiber.Ctx) error {
+	var params types.CreateFeedbackParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	id := c.Params("id")
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	booking, err := h.store.Booking.GetBookingByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("booking")
+	}
+
+	if booking.UserID != user.ID {
+		return ErrUnauthorized()
+	}
+
+	if booking.Canceled || !booking.Date.Before(time.Now()) {
+		return NewError(http.StatusForbidden, "cinemas can only be rated after a visit")
+	}
+
+	hall, err := h.store.Hall.GetHallByID(c.Context(), booking.HallID)
+	if err != nil {
+		return ErrResourceNotFound("hall")
+	}
+
+	feedback := types.NewFeedbackFromParams(params, booking, hall, user)
+	inserted, err := h.store.Feedback.InsertFeedback(c.Context(), feedback)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return NewError(http.StatusConflict, "you have already rated this visit")
+	}
+
+	if err := h.store.Cinema.AddCinemaRating(c.Context(), feedback.CinemaID, feedback.Stars); err != nil {
+		return err
+	}
+
+	return c.JSON(feedback)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostFeedback(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user            = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		otherUser       = fixtures.AddUser(tdb.Store, "james", "foo", false)
+		cinema          = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie           = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall            = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		visit           = fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, -2))
+		upcoming        = fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, 2))
+		otherVisit      = fixtures.AddBooking(tdb.Store, otherUser.ID, hall.ID, types.Night, time.Now().AddDate(0, 0, -1))
+		app             = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1           = app.Group("/", JWTAuthentication(tdb.User))
+		cinemaHandler   = NewCinemaHandler(tdb.Store, tdb.blobs)
+		feedbackHandler = NewFeedbackHandler(tdb.Store)
+	)
+
+	apiV1.Get("/cinema", cinemaHandler.HandleGetCinemas)
+	apiV1.Post("/booking/:id/feedback", feedbackHandler.HandlePostFeedback)
+
+	rate := func(user *types.User, booking *types.Booking, stars int) int {
+		b, _ := json.Marshal(types.CreateFeedbackParams{Stars: stars})
+		req := httptest.NewRequest("POST", "/booking/"+booking.ID.Hex()+"/feedback", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp.StatusCode
+	}
+
+	if code := rate(user, upcoming, 5); code != http.StatusForbidden {
+		t.Fatalf("expected status code %d before the visit, got %d", http.StatusForbidden, code)
+	}
+	if code := rate(otherUser, visit, 5); code != http.StatusUnauthorized {
+		t.Fatalf("expected status code %d for someone else's booking, got %d", http.StatusUnauthorized, code)
+	}
+	if code := rate(user, visit, 5); code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+	if code := rate(user, visit, 1); code != http.StatusConflict {
+		t.Fatalf("expected status code %d for rating a visit twice, got %d", http.StatusConflict, code)
+	}
+	if code := rate(otherUser, otherVisit, 2); code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+
+	rated, err := tdb.Cinema.GetCinemaByID(context.TODO(), cinema.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rated.Rating.Average != 3.5 || rated.Rating.Count != 2 {
+		t.Fatalf("expected a rating of 3.5 from 2 visits, got %+v", rated.Rating)
+	}
+
+	fixtures.AddCinema(tdb.Store, "delphi", "berlin", nil)
+
+	for minRating, expected := range map[string]int{"3": 1, "4": 0} {
+		req := httptest.NewRequest("GET", "/cinema?minRating="+minRating, nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var found []types.Cinema
+		if err := json.NewDecoder(resp.Body).Decode(&ResourceResponse{Data: &found}); err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != expected {
+			t.Fatalf("expected %d cinemas rated at least %s, got %d", expected, minRating, len(found))
+		}
+	}
+}
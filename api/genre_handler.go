@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+)
+
+type GenreHandler struct {
+	store *db.Store
+}
+
+func NewGenreHandler(store *db.Store) *GenreHandler {
+	return &GenreHandler{
+		store: store,
+	}
+}
+
+func (h *GenreHandler) HandleGetGenres(c *fiber.Ctx) error {
+	genres, err := h.store.Genre.GetGenres(c.Context(), db.Map{})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(genres)
+}
+
+func (h *GenreHandler) HandlePostGenre(c *fiber.Ctx) error {
+	var params types.CreateGenreParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	genre, err := h.store.Genre.InsertGenre(c.Context(), &types.GenreInfo{ID: params.ID, Name: params.Name})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return NewError(http.StatusConflict, fmt.Sprintf("genre %s already exists", params.ID))
+		}
+
+		return err
+	}
+
+	return c.JSON(genre)
+}
+
+func (h *GenreHandler) HandlePutGenre(c *fiber.Ctx) error {
+	var (
+		params types.UpdateGenreParams
+		id     = types.Genre(c.Params("id"))
+	)
+
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	if err := h.store.Genre.UpdateGenre(c.Context(), id, db.Map{"name": params.Name}); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("genre")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"updated": string(id)})
+}
+
+// HandleDeleteGenre refuses to delete genres that movies are still tagged
+// with.
+func (h *GenreHandler) HandleDeleteGenre(c *fiber.Ctx) error {
+	id := types.Genre(c.Params("id"))
+
+	movies, err := h.store.Movie.CountMovies(c.Context(), db.Map{"genres": id})
+	if err != nil {
+		return err
+	}
+	if movies > 0 {
+		return NewError(http.StatusConflict, fmt.Sprintf("genre is used by %d movie(s)", movies))
+	}
+
+	if err := h.store.Genre.DeleteGenre(c.Context(), id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("genre")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"deleted": string(id)})
+}
+
+// checkGenres makes sure all genres are in the catalog.
+func checkGenres(ctx context.Context, store *db.Store, genres []types.Genre) error {
+	if len(genres) == 0 {
+		return nil
+	}
+
+	known, err := store.Genre.GetGenres(ctx, db.Map{"_id": db.Map{"$in": genres}})
+	if err != nil {
+		return err
+	}
+	if len(known) == len(genres) {
+		return nil
+	}
+
+	for _, genre := range genres {
+		found := false
+		for _, info := range known {
+			if info.ID == genre {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return NewError(http.StatusBadRequest, fmt.Sprintf("unknown genre %s", genre))
+		}
+	}
+
+	return nil
+}
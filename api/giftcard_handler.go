@@ -0,0 +1,85 @@
+package api
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+)
+
+type GiftCardHandler struct {
+	store *db.Store
+}
+
+func NewGiftCardHandler(store *db.Store) *GiftCardHandler {
+	return &GiftCardHandler{
+		store: store,
+	}
+}
+
+func (h *GiftCardHandler) HandlePostGiftCard(c *fiber.Ctx) error {
+	var params types.CreateGiftCardParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	admin, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	card, err := types.NewGiftCardFromParams(params, admin)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := h.store.GiftCard.InsertGiftCard(c.Context(), card)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(inserted)
+}
+
+type GiftCardQueryParams struct {
+	db.Pagination
+	Disabled bool
+}
+
+func (h *GiftCardHandler) HandleGetGiftCards(c *fiber.Ctx) error {
+	var params GiftCardQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	filter := db.Map{
+		"disabled": params.Disabled,
+	}
+	cards, err := h.store.GiftCard.GetGiftCards(c.Context(), filter, &params.Pagination)
+	if err != nil {
+		return ErrResourceNotFound("gift card")
+	}
+
+	resp := ResourceResponse{
+		Results: len(cards),
+		Data:    cards,
+		Page:    int(params.Page),
+	}
+	return c.JSON(resp)
+}
+
+// HandleGetGiftCard lets customers check the balance of a card they hold.
+func (h *GiftCardHandler) HandleGetGiftCard(c *fiber.Ctx) error {
+	code := types.NormalizeGiftCardCode(c.Params("code"))
+
+	card, err := h.store.GiftCard.GetGiftCardByCode(c.Context(), code)
+	if err != nil {
+		return ErrResourceNotFound("gift card")
+	}
+
+	return c.JSON(card)
+}
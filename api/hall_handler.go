@@ -2,18 +2,26 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type BookHallParams struct {
-	Session types.Session `json:"session"`
-	Date    time.Time     `json:"date"`
+	Session      types.Session       `json:"session"`
+	Date         time.Time           `json:"date"`
+	GiftCardCode string              `json:"giftCardCode"`
+	UseWallet    bool                `json:"useWallet"`
+	RedeemPoints bool                `json:"redeemPoints"`
+	UsePass      bool                `json:"usePass"`
+	AddOns       []types.AddOnParams `json:"addOns"`
 }
 
 func (p BookHallParams) validate() error {
@@ -39,13 +47,117 @@ func NewHallHandler(store *db.Store) *HallHandler {
 	}
 }
 
+type HallQueryParams struct {
+	db.ListOptions
+	Movie       string
+	Cinema      string
+	MinPrice    float64
+	MaxPrice    float64
+	MinCapacity int
+	MaxCapacity int
+	// Feature is a comma separated list of features halls need to have all
+	// of.
+	Feature string
+}
+
+func (p HallQueryParams) filter() (db.Map, error) {
+	filter := db.Map{}
+
+	if len(p.Movie) > 0 {
+		movieID, err := primitive.ObjectIDFromHex(p.Movie)
+		if err != nil {
+			return nil, ErrInvalidID()
+		}
+		filter["movie"] = movieID
+	}
+
+	if len(p.Cinema) > 0 {
+		cinemaID, err := primitive.ObjectIDFromHex(p.Cinema)
+		if err != nil {
+			return nil, ErrInvalidID()
+		}
+		filter["cinema"] = cinemaID
+	}
+
+	price := db.Map{}
+	if p.MinPrice > 0 {
+		price["$gte"] = p.MinPrice
+	}
+	if p.MaxPrice > 0 {
+		price["$lte"] = p.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+
+	capacity := db.Map{}
+	if p.MinCapacity > 0 {
+		capacity["$gte"] = p.MinCapacity
+	}
+	if p.MaxCapacity > 0 {
+		capacity["$lte"] = p.MaxCapacity
+	}
+	if len(capacity) > 0 {
+		filter["capacity"] = capacity
+	}
+
+	if len(p.Feature) > 0 {
+		features, err := parseFeatures(p.Feature)
+		if err != nil {
+			return nil, err
+		}
+		filter["features"] = db.Map{"$all": features}
+	}
+
+	return filter, nil
+}
+
+// parseFeatures parses a comma separated list of hall features.
+func parseFeatures(list string) ([]types.HallFeature, error) {
+	features := []types.HallFeature{}
+	for _, tag := range strings.Split(list, ",") {
+		feature := types.HallFeature(strings.ToLower(strings.TrimSpace(tag)))
+		if !feature.IsValid() {
+			return nil, NewError(http.StatusBadRequest, fmt.Sprintf("unknown feature %q", tag))
+		}
+		features = append(features, feature)
+	}
+
+	return features, nil
+}
+
 func (h *HallHandler) HandleGetHalls(c *fiber.Ctx) error {
-	halls, err := h.store.Hall.GetHalls(c.Context(), db.Map{})
+	var params HallQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if err := checkSort(params.Sort, "price", "capacity"); err != nil {
+		return err
+	}
+
+	filter, err := params.filter()
+	if err != nil {
+		return err
+	}
+
+	halls, err := h.store.Hall.GetHalls(c.Context(), filter, &params.ListOptions)
 	if err != nil {
 		return ErrResourceNotFound("hall")
 	}
 
-	return c.JSON(halls)
+	total, err := h.store.Hall.CountHalls(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	resp := ResourceResponse{
+		Results: len(halls),
+		Data:    halls,
+		Page:    int(params.Page),
+		Total:   total,
+	}
+	return c.JSON(resp)
 }
 
 func (h *HallHandler) HandleBookHall(c *fiber.Ctx) error {
@@ -77,28 +189,257 @@ func (h *HallHandler) HandleBookHall(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(map[string]string{"message": fmt.Sprintf("Hall %s is full.", hallID.Hex())})
 	}
 
+	hall, err := h.getBookableHall(c.Context(), hallID)
+	if err != nil {
+		return err
+	}
+
+	addOns, err := resolveAddOns(c.Context(), h.store, hall, params.AddOns)
+	if err != nil {
+		return err
+	}
+
+	price, err := priceBooking(c.Context(), h.store, hall, addOns)
+	if err != nil {
+		return err
+	}
+
 	booking := types.Booking{
-		UserID:  user.ID,
-		HallID:  hallID,
-		Session: params.Session,
-		Date:    params.Date,
+		ID:             primitive.NewObjectID(),
+		UserID:         user.ID,
+		HallID:         hallID,
+		MovieID:        hall.Movie,
+		OrganizationID: hall.OrganizationID,
+		Session:        params.Session,
+		Date:           params.Date,
+		Price:          price,
+		AddOns:         addOns,
+	}
+
+	if err := reserveAddOns(c.Context(), h.store, addOns); err != nil {
+		return err
+	}
+
+	if err := chargeBooking(c.Context(), h.store, user, &booking, params); err != nil {
+		releaseAddOns(c.Context(), h.store, addOns)
+		return err
 	}
 
 	inserted, err := h.store.Booking.InsertBooking(c.Context(), &booking)
 	if err != nil {
+		releaseAddOns(c.Context(), h.store, addOns)
+		if err := reversePayments(c.Context(), h.store, &booking); err != nil {
+			return err
+		}
+
 		return err
 	}
 
+	if err := accrueLoyaltyPoints(c.Context(), h.store, inserted, inserted.Payments); err != nil {
+		fmt.Println("Error accruing loyalty points:", err)
+	}
+
 	return c.JSON(inserted)
 }
 
-func (h *HallHandler) isHallAvailableForBooking(ctx context.Context, hallID primitive.ObjectID, session types.Session) (bool, error) {
-	where := db.Map{
-		"hallID":   hallID,
-		"session":  session,
-		"canceled": false,
+func (h *HallHandler) HandlePostHall(c *fiber.Ctx) error {
+	var params types.CreateHallParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	hall := types.NewHallFromParams(params)
+	if _, err := h.store.Movie.GetMovieByID(c.Context(), params.Movie); err != nil {
+		return ErrResourceNotFound("movie")
+	}
+
+	cinema, err := h.getOpenCinema(c.Context(), hall.Cinema)
+	if err != nil {
+		return err
+	}
+	hall.OrganizationID = cinema.OrganizationID
+
+	inserted, err := h.store.Hall.InsertHall(c.Context(), hall)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(inserted)
+}
+
+// HandlePutHall updates a hall. Halls with upcoming bookings can not be moved
+// to another cinema, and their capacity can not be reduced below the number
+// of seats already booked for a session.
+func (h *HallHandler) HandlePutHall(c *fiber.Ctx) error {
+	var (
+		params types.UpdateHallParams
+		id     = c.Params("id")
+	)
+
+	hallID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	update := db.Map(params.ToBSON())
+	if len(update) == 0 {
+		return NewError(http.StatusBadRequest, "nothing to update")
+	}
+
+	hall, err := h.store.Hall.GetHallByID(c.Context(), hallID)
+	if err != nil {
+		return ErrResourceNotFound("hall")
+	}
+
+	if len(params.Movie) > 0 {
+		if _, err := h.store.Movie.GetMovieByID(c.Context(), params.Movie); err != nil {
+			return ErrResourceNotFound("movie")
+		}
+	}
+
+	if cinemaID, ok := update["cinema"].(primitive.ObjectID); ok && cinemaID != hall.Cinema {
+		cinema, err := h.getOpenCinema(c.Context(), cinemaID)
+		if err != nil {
+			return err
+		}
+		if cinema.OrganizationID != hall.OrganizationID {
+			update["organizationID"] = cinema.OrganizationID
+		}
+
+		upcoming, err := h.countUpcomingBookings(c.Context(), hallID)
+		if err != nil {
+			return err
+		}
+		if upcoming > 0 {
+			return NewError(http.StatusConflict, fmt.Sprintf("hall has %d upcoming booking(s) and can not be moved", upcoming))
+		}
+	}
+
+	if params.Capacity != nil && *params.Capacity < hall.Capacity {
+		booked, err := h.maxSessionBookings(c.Context(), hallID)
+		if err != nil {
+			return err
+		}
+		if *params.Capacity < booked {
+			return NewError(http.StatusConflict, fmt.Sprintf("capacity can not be reduced below %d booked seats", booked))
+		}
+	}
+
+	if err := h.store.Hall.UpdateHall(c.Context(), hallID, update); err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]string{"updated": id})
+}
+
+// HandleDeleteHall refuses to delete halls with upcoming bookings.
+func (h *HallHandler) HandleDeleteHall(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	hallID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
 	}
-	bookingsCount, err := h.store.Booking.CountBookings(ctx, where)
+
+	upcoming, err := h.countUpcomingBookings(c.Context(), hallID)
+	if err != nil {
+		return err
+	}
+	if upcoming > 0 {
+		return NewError(http.StatusConflict, fmt.Sprintf("hall has %d upcoming booking(s)", upcoming))
+	}
+
+	if err := h.store.Hall.DeleteHall(c.Context(), hallID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("hall")
+		}
+
+		return err
+	}
+
+	deleted, err := clearProgramme(c.Context(), h.store, db.Map{"hallID": hallID})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]any{"deleted": id, "deletedShowtimes": deleted})
+}
+
+type QuoteParams struct {
+	HallID string `json:"hallID"`
+	BookHallParams
+}
+
+type QuoteResponse struct {
+	Available bool                  `json:"available"`
+	SeatsLeft int                   `json:"seatsLeft"`
+	Price     *types.PriceBreakdown `json:"price"`
+}
+
+// HandleQuote prices a booking request exactly like HandleBookHall would,
+// without reserving seats or add-ons and without charging anything.
+func (h *HallHandler) HandleQuote(c *fiber.Ctx) error {
+	var params QuoteParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	hallID, err := primitive.ObjectIDFromHex(params.HallID)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := params.validate(); err != nil {
+		return err
+	}
+
+	hall, err := h.getBookableHall(c.Context(), hallID)
+	if err != nil {
+		return err
+	}
+
+	addOns, err := resolveAddOns(c.Context(), h.store, hall, params.AddOns)
+	if err != nil {
+		return err
+	}
+
+	price, err := priceBooking(c.Context(), h.store, hall, addOns)
+	if err != nil {
+		return err
+	}
+
+	booked, err := h.countSessionBookings(c.Context(), hallID, params.Session)
+	if err != nil {
+		return err
+	}
+
+	seatsLeft := hall.Capacity - booked
+	if seatsLeft < 0 {
+		seatsLeft = 0
+	}
+
+	resp := QuoteResponse{
+		Available: seatsLeft > 0,
+		SeatsLeft: seatsLeft,
+		Price:     price,
+	}
+	return c.JSON(resp)
+}
+
+func (h *HallHandler) isHallAvailableForBooking(ctx context.Context, hallID primitive.ObjectID, session types.Session) (bool, error) {
+	bookingsCount, err := h.countSessionBookings(ctx, hallID, session)
 	if err != nil {
 		return false, ErrResourceNotFound("booking")
 	}
@@ -113,3 +454,72 @@ func (h *HallHandler) isHallAvailableForBooking(ctx context.Context, hallID prim
 
 	return true, nil
 }
+
+// countSessionBookings counts the bookings holding a seat in the session of
+// the hall. Past and canceled bookings do not hold on to a seat.
+func (h *HallHandler) countSessionBookings(ctx context.Context, hallID primitive.ObjectID, session types.Session) (int, error) {
+	where := db.Map{
+		"hallID":   hallID,
+		"session":  session,
+		"canceled": false,
+		"date":     db.Map{"$gte": time.Now().UTC().Truncate(24 * time.Hour)},
+	}
+
+	return h.store.Booking.CountBookings(ctx, where)
+}
+
+// getBookableHall returns the hall unless its cinema has been retired.
+func (h *HallHandler) getBookableHall(ctx context.Context, hallID primitive.ObjectID) (*types.Hall, error) {
+	hall, err := h.store.Hall.GetHallByID(ctx, hallID)
+	if err != nil {
+		return nil, ErrResourceNotFound("hall")
+	}
+
+	if _, err := h.getOpenCinema(ctx, hall.Cinema); err != nil {
+		return nil, err
+	}
+
+	return hall, nil
+}
+
+// getOpenCinema returns the cinema unless it has been retired.
+func (h *HallHandler) getOpenCinema(ctx context.Context, cinemaID primitive.ObjectID) (*types.Cinema, error) {
+	cinema, err := h.store.Cinema.GetCinemaByID(ctx, cinemaID.Hex())
+	if err != nil {
+		return nil, ErrResourceNotFound("cinema")
+	}
+
+	if cinema.Retired {
+		return nil, NewError(http.StatusBadRequest, fmt.Sprintf("%s is closed", cinema.Name))
+	}
+
+	return cinema, nil
+}
+
+func (h *HallHandler) countUpcomingBookings(ctx context.Context, hallID primitive.ObjectID) (int, error) {
+	where := db.Map{
+		"hallID":   hallID,
+		"canceled": false,
+		"date":     db.Map{"$gte": time.Now()},
+	}
+
+	return h.store.Booking.CountBookings(ctx, where)
+}
+
+// maxSessionBookings returns the highest number of seats any session of the
+// hall holds, as counted when booking it, which is the lowest capacity the
+// hall can be reduced to.
+func (h *HallHandler) maxSessionBookings(ctx context.Context, hallID primitive.ObjectID) (int, error) {
+	most := 0
+	for session := types.Morning; session <= types.Night; session++ {
+		booked, err := h.countSessionBookings(ctx, hallID, session)
+		if err != nil {
+			return 0, err
+		}
+		if booked > most {
+			most = booked
+		}
+	}
+
+	return most, nil
+}
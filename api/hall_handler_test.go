@@ -0,0 +1,691 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBookHallWithGiftCardAndWallet(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user           = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		cinema         = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie          = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall           = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		card           = fixtures.AddGiftCard(tdb.Store, "ABCD-EFGH-JKLM-NPQR", 4.0)
+		adminUser      = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		app            = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		route          = app.Group("/", JWTAuthentication(tdb.User))
+		admin          = app.Group("/admin", JWTAuthentication(tdb.User), AdminAuth)
+		hallHandler    = NewHallHandler(tdb.Store)
+		bookingHandler = NewBookingHandler(tdb.Store)
+	)
+
+	route.Post("/hall/:id/book", hallHandler.HandleBookHall)
+	route.Get("/booking/:id/cancel", bookingHandler.HandleCancelBooking)
+	admin.Post("/booking/:id/capture", bookingHandler.HandleCapturePayment)
+
+	params := BookHallParams{
+		Session:      types.Evening,
+		Date:         time.Now().AddDate(0, 0, 1),
+		GiftCardCode: "abcd-efgh-jklm-npqr",
+	}
+	b, _ := json.Marshal(params)
+
+	req := httptest.NewRequest("POST", "/hall/"+hall.ID.Hex()+"/book", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var booking types.Booking
+	if err := json.NewDecoder(resp.Body).Decode(&booking); err != nil {
+		t.Fatal(err)
+	}
+	if len(booking.Payments) != 2 {
+		t.Fatalf("expected gift card and gateway payments, got %+v", booking.Payments)
+	}
+	if booking.Payments[0].Method != types.PaymentGiftCard || booking.Payments[0].Amount != 4.0 {
+		t.Fatalf("expected 4.00 to be paid by gift card, got %+v", booking.Payments[0])
+	}
+	if booking.Payments[1].Method != types.PaymentGateway || booking.Payments[1].Amount != 6.0 {
+		t.Fatalf("expected 6.00 to be left to the gateway, got %+v", booking.Payments[1])
+	}
+	if booking.Payments[1].IsCaptured() {
+		t.Fatal("expected the gateway payment to be pending")
+	}
+
+	redeemed, err := tdb.GiftCard.GetGiftCardByCode(context.TODO(), card.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemed.Balance != 0 {
+		t.Fatalf("expected the gift card to be used up, got balance %.2f", redeemed.Balance)
+	}
+
+	// the gateway payment is captured once
+	for i, expected := range []int{http.StatusOK, http.StatusConflict} {
+		b, _ = json.Marshal(types.CapturePaymentParams{Reference: "ch_3NfXkQ"})
+		req = httptest.NewRequest("POST", "/admin/booking/"+booking.ID.Hex()+"/capture", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+
+		resp, err = app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("capture #%d: expected status code %d, got %d", i+1, expected, resp.StatusCode)
+		}
+	}
+
+	// canceling credits the captured amount to the wallet, but only once
+	for i, expected := range []int{http.StatusOK, http.StatusBadRequest} {
+		req = httptest.NewRequest("GET", "/booking/"+booking.ID.Hex()+"/cancel", nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err = app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("cancel #%d: expected status code %d, got %d", i+1, expected, resp.StatusCode)
+		}
+	}
+
+	balances, err := tdb.Wallet.GetWalletBalances(context.TODO(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[types.DefaultCurrency] != 10.0 {
+		t.Fatalf("expected a wallet balance of 10.00, got %.2f", balances[types.DefaultCurrency])
+	}
+
+	// the next booking is paid from the wallet
+	params = BookHallParams{
+		Session:   types.Evening,
+		Date:      time.Now().AddDate(0, 0, 1),
+		UseWallet: true,
+	}
+	b, _ = json.Marshal(params)
+
+	req = httptest.NewRequest("POST", "/hall/"+hall.ID.Hex()+"/book", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	booking = types.Booking{}
+	if err := json.NewDecoder(resp.Body).Decode(&booking); err != nil {
+		t.Fatal(err)
+	}
+	if len(booking.Payments) != 1 || booking.Payments[0].Method != types.PaymentWallet {
+		t.Fatalf("expected the booking to be paid from the wallet, got %+v", booking.Payments)
+	}
+}
+
+func TestBookHallAccruesLoyaltyPoints(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user           = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		adminUser      = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		cinema         = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie          = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall           = fixtures.AddHall(tdb.Store, 100, 12.5, cinema.ID, movie.ID)
+		app            = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		route          = app.Group("/", JWTAuthentication(tdb.User))
+		admin          = app.Group("/admin", JWTAuthentication(tdb.User), AdminAuth)
+		hallHandler    = NewHallHandler(tdb.Store)
+		bookingHandler = NewBookingHandler(tdb.Store)
+		loyaltyHandler = NewLoyaltyHandler(tdb.Store)
+	)
+
+	program := types.DefaultLoyaltyProgram()
+	program.SessionBonuses = []types.SessionBonus{{Session: types.Morning, Points: 5}}
+	if err := tdb.Loyalty.UpdateLoyaltyProgram(context.TODO(), program); err != nil {
+		t.Fatal(err)
+	}
+
+	route.Post("/hall/:id/book", hallHandler.HandleBookHall)
+	route.Get("/booking/:id/cancel", bookingHandler.HandleCancelBooking)
+	route.Get("/me/loyalty", loyaltyHandler.HandleGetLoyalty)
+	admin.Post("/booking/:id/capture", bookingHandler.HandleCapturePayment)
+
+	getBalance := func() int {
+		req := httptest.NewRequest("GET", "/me/loyalty?page=1&limit=10", nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var loyalty LoyaltyResponse
+		if err := json.NewDecoder(resp.Body).Decode(&loyalty); err != nil {
+			t.Fatal(err)
+		}
+
+		return loyalty.Balance
+	}
+
+	b, _ := json.Marshal(BookHallParams{Session: types.Morning, Date: time.Now().AddDate(0, 0, 1)})
+	req := httptest.NewRequest("POST", "/hall/"+hall.ID.Hex()+"/book", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var booking types.Booking
+	if err := json.NewDecoder(resp.Body).Decode(&booking); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is earned before the gateway collected the money
+	if balance := getBalance(); balance != 0 {
+		t.Fatalf("expected no points for a pending payment, got %d", balance)
+	}
+
+	b, _ = json.Marshal(types.CapturePaymentParams{Reference: "ch_3NfXkQ"})
+	req = httptest.NewRequest("POST", "/admin/booking/"+booking.ID.Hex()+"/capture", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if balance := getBalance(); balance != 17 {
+		t.Fatalf("expected 12 points plus a bonus of 5, got %d", balance)
+	}
+
+	req = httptest.NewRequest("GET", "/booking/"+booking.ID.Hex()+"/cancel", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if balance := getBalance(); balance != 0 {
+		t.Fatalf("expected the points to be reversed, got %d", balance)
+	}
+}
+
+func TestBookHallWithAddOns(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user              = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		adminUser         = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		cinema            = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie             = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall              = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		popcorn           = fixtures.AddConcession(tdb.Store, cinema.ID, "popcorn", 5.5, 10)
+		app               = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		route             = app.Group("/", JWTAuthentication(tdb.User))
+		admin             = app.Group("/admin", JWTAuthentication(tdb.User), AdminAuth)
+		hallHandler       = NewHallHandler(tdb.Store)
+		bookingHandler    = NewBookingHandler(tdb.Store)
+		concessionHandler = NewConcessionHandler(tdb.Store)
+	)
+
+	route.Post("/hall/:id/book", hallHandler.HandleBookHall)
+	route.Get("/booking/:id/cancel", bookingHandler.HandleCancelBooking)
+	admin.Post("/booking/:id/capture", bookingHandler.HandleCapturePayment)
+	admin.Post("/booking/:id/pickup", concessionHandler.HandlePickup)
+
+	params := BookHallParams{
+		Session: types.Evening,
+		Date:    time.Now().AddDate(0, 0, 1),
+		AddOns:  []types.AddOnParams{{ConcessionID: popcorn.ID.Hex(), Quantity: 2}},
+	}
+	b, _ := json.Marshal(params)
+
+	req := httptest.NewRequest("POST", "/hall/"+hall.ID.Hex()+"/book", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var booking types.Booking
+	if err := json.NewDecoder(resp.Body).Decode(&booking); err != nil {
+		t.Fatal(err)
+	}
+	if len(booking.Price.Items) != 2 || booking.Price.Gross != 21.0 {
+		t.Fatalf("expected a ticket and two popcorn for 21.00, got %+v", booking.Price)
+	}
+
+	stocked, err := tdb.Concession.GetConcessionByID(context.TODO(), popcorn.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stocked.Stock != 8 {
+		t.Fatalf("expected 8 popcorn left in stock, got %d", stocked.Stock)
+	}
+
+	req = httptest.NewRequest("POST", "/admin/booking/"+booking.ID.Hex()+"/pickup", bytes.NewReader([]byte("{}")))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var addOns []types.AddOn
+	if err := json.NewDecoder(resp.Body).Decode(&addOns); err != nil {
+		t.Fatal(err)
+	}
+	if len(addOns) != 1 || !addOns[0].Collected {
+		t.Fatalf("expected the popcorn to be collected, got %+v", addOns)
+	}
+
+	// the collected popcorn is not refunded when the booking is canceled
+	b, _ = json.Marshal(types.CapturePaymentParams{Reference: "ch_3NfXkQ"})
+	req = httptest.NewRequest("POST", "/admin/booking/"+booking.ID.Hex()+"/capture", bytes.NewReader(b))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+
+	if resp, err = app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	req = httptest.NewRequest("GET", "/booking/"+booking.ID.Hex()+"/cancel", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	if resp, err = app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	balances, err := tdb.Wallet.GetWalletBalances(context.TODO(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[types.DefaultCurrency] != 10.0 {
+		t.Fatalf("expected the ticket only to be refunded, got a wallet balance of %.2f", balances[types.DefaultCurrency])
+	}
+}
+
+func TestAdminHallCRUD(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser   = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		user        = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		cinema      = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		otherCinema = fixtures.AddCinema(tdb.Store, "delphi", "berlin", nil)
+		movie       = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		app         = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin       = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		hallHandler = NewHallHandler(tdb.Store)
+		token       = CreateTokenFromUser(adminUser)
+	)
+
+	admin.Post("/hall", hallHandler.HandlePostHall)
+	admin.Put("/hall/:id", hallHandler.HandlePutHall)
+	admin.Delete("/hall/:id", hallHandler.HandleDeleteHall)
+
+	send := func(method, target string, body any) *http.Response {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	resp := send("POST", "/hall", types.CreateHallParams{Capacity: 3, Price: 10.0, Movie: movie.ID.Hex(), Cinema: cinema.ID.Hex()})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var hall types.Hall
+	if err := json.NewDecoder(resp.Body).Decode(&hall); err != nil {
+		t.Fatal(err)
+	}
+
+	// the hall can be moved while it has no upcoming bookings
+	resp = send("PUT", "/hall/"+hall.ID.Hex(), types.UpdateHallParams{Cinema: otherCinema.ID.Hex()})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	oldCinema, err := tdb.Cinema.GetCinemaByID(context.TODO(), cinema.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCinema, err := tdb.Cinema.GetCinemaByID(context.TODO(), otherCinema.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oldCinema.Halls) != 0 || len(newCinema.Halls) != 1 || newCinema.Halls[0] != hall.ID {
+		t.Fatalf("expected the hall to be moved between cinemas, got %v and %v", oldCinema.Halls, newCinema.Halls)
+	}
+
+	// past and canceled bookings do not hold on to seats
+	for i := 0; i < 3; i++ {
+		fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, -3))
+	}
+	canceled := fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, 3))
+	if err := tdb.Booking.CancelBooking(context.TODO(), canceled.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+
+	capacity := 2
+	resp = send("PUT", "/hall/"+hall.ID.Hex(), types.UpdateHallParams{Capacity: &capacity})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	// and neither do they keep the session booked out
+	available, err := hallHandler.isHallAvailableForBooking(context.TODO(), hall.ID, types.Evening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !available {
+		t.Fatal("expected past bookings not to count against the reduced capacity")
+	}
+
+	fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, 3))
+	fixtures.AddBooking(tdb.Store, user.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, 3))
+
+	capacity = 1
+	resp = send("PUT", "/hall/"+hall.ID.Hex(), types.UpdateHallParams{Capacity: &capacity})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d when reducing capacity below booked seats, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	resp = send("DELETE", "/hall/"+hall.ID.Hex(), nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d when deleting a hall with bookings, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
+
+func TestGetHallsPaginated(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user        = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		cinema      = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie       = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		app         = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1       = app.Group("/", JWTAuthentication(tdb.User))
+		hallHandler = NewHallHandler(tdb.Store)
+	)
+
+	apiV1.Get("/hall", hallHandler.HandleGetHalls)
+
+	for i := 1; i <= 5; i++ {
+		fixtures.AddHall(tdb.Store, 50*i, float64(5+i), cinema.ID, movie.ID)
+	}
+
+	req := httptest.NewRequest("GET", "/hall?minPrice=7&sort=-capacity&limit=2&page=1", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var halls []types.Hall
+	response := ResourceResponse{Data: &halls}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Total != 4 || response.Results != 2 {
+		t.Fatalf("expected 2 of 4 halls, got %d of %d", response.Results, response.Total)
+	}
+	if halls[0].Capacity != 250 || halls[1].Capacity != 200 {
+		t.Fatalf("expected the largest halls first, got %+v", halls)
+	}
+
+	req = httptest.NewRequest("GET", "/hall?sort=password", nil)
+	req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d for an unknown sort field, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestGetHallsByFeature(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user        = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		cinema      = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie       = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		plain       = fixtures.AddHall(tdb.Store, 100, 10, cinema.ID, movie.ID)
+		app         = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1       = app.Group("/", JWTAuthentication(tdb.User))
+		hallHandler = NewHallHandler(tdb.Store)
+	)
+
+	apiV1.Get("/hall", hallHandler.HandleGetHalls)
+	apiV1.Post("/quote", hallHandler.HandleQuote)
+
+	imax, err := tdb.Hall.InsertHall(context.TODO(), &types.Hall{
+		Capacity: 200,
+		Price:    10,
+		Cinema:   cinema.ID,
+		Movie:    movie.ID,
+		Features: []types.HallFeature{types.FeatureIMAX, types.FeatureDolbyAtmos},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	surcharges := map[types.HallFeature]float64{types.FeatureIMAX: 4}
+	update := map[string]any{"$set": map[string]any{"surcharges": surcharges}}
+	if err := tdb.Cinema.UpdateCinema(context.TODO(), map[string]any{"_id": cinema.ID}, update); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(target string) *http.Response {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	resp := get("/hall?feature=imax,dolby-atmos")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var halls []types.Hall
+	response := ResourceResponse{Data: &halls}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(halls) != 1 || halls[0].ID != imax.ID {
+		t.Fatalf("expected only the IMAX hall, got %+v", halls)
+	}
+
+	resp = get("/hall?feature=smell-o-vision")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d for an unknown feature, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	quote := func(hall *types.Hall) *types.PriceBreakdown {
+		b, _ := json.Marshal(QuoteParams{
+			HallID:         hall.ID.Hex(),
+			BookHallParams: BookHallParams{Session: types.Evening, Date: time.Now().AddDate(0, 0, 1)},
+		})
+		req := httptest.NewRequest("POST", "/quote", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var response QuoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		return response.Price
+	}
+
+	if price := quote(plain); len(price.Items) != 1 {
+		t.Fatalf("expected no surcharge for a plain hall, got %+v", price.Items)
+	}
+
+	price := quote(imax)
+	if len(price.Items) != 2 || price.Items[1].Description != "IMAX surcharge" || price.Items[1].UnitPrice != 4 {
+		t.Fatalf("expected only an IMAX surcharge of 4, got %+v", price.Items)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user        = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		cinema      = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie       = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		popcorn     = fixtures.AddConcession(tdb.Store, cinema.ID, "popcorn", 5.95, 10)
+		app         = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1       = app.Group("/", JWTAuthentication(tdb.User))
+		hallHandler = NewHallHandler(tdb.Store)
+	)
+
+	apiV1.Post("/quote", hallHandler.HandleQuote)
+
+	fixtures.AddTaxRate(tdb.Store, "reduced", "", cinema.ID, types.ProductTicket, 7)
+	fixtures.AddTaxRate(tdb.Store, "standard", "", cinema.ID, types.ProductConcession, 19)
+
+	hall, err := tdb.Hall.InsertHall(context.TODO(), &types.Hall{
+		Capacity: 50,
+		Price:    10.70,
+		Cinema:   cinema.ID,
+		Movie:    movie.ID,
+		Features: []types.HallFeature{types.FeatureIMAX},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	surcharges := map[types.HallFeature]float64{types.FeatureIMAX: 2.14}
+	update := map[string]any{"$set": map[string]any{"surcharges": surcharges}}
+	if err := tdb.Cinema.UpdateCinema(context.TODO(), map[string]any{"_id": cinema.ID}, update); err != nil {
+		t.Fatal(err)
+	}
+
+	quote := func() QuoteResponse {
+		b, _ := json.Marshal(QuoteParams{
+			HallID: hall.ID.Hex(),
+			BookHallParams: BookHallParams{
+				Session: types.Evening,
+				Date:    time.Now().AddDate(0, 0, 1),
+				AddOns:  []types.AddOnParams{{ConcessionID: popcorn.ID.Hex(), Quantity: 2}},
+			},
+		})
+		req := httptest.NewRequest("POST", "/quote", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		var response QuoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		return response
+	}
+
+	response := quote()
+	price := response.Price
+	if len(price.Items) != 3 {
+		t.Fatalf("expected ticket, surcharge and popcorn items, got %+v", price.Items)
+	}
+	if item := price.Items[1]; item.Description != "IMAX surcharge" || item.Net != 2.0 || item.Tax != 0.14 {
+		t.Fatalf("expected an IMAX surcharge of 2.00 plus 0.14 tax, got %+v", item)
+	}
+	if item := price.Items[2]; item.ProductType != types.ProductConcession || item.Gross != 11.90 || item.Tax != 1.90 {
+		t.Fatalf("expected 2 popcorn for 11.90 including 1.90 tax, got %+v", item)
+	}
+	if price.Net != 22.0 || price.Tax != 2.74 || price.Gross != 24.74 {
+		t.Fatalf("expected 22.00 net, 2.74 tax and 24.74 gross, got %.2f, %.2f and %.2f", price.Net, price.Tax, price.Gross)
+	}
+	if len(price.Taxes) != 2 || price.Taxes[0].Tax != 0.84 || price.Taxes[1].Tax != 1.90 {
+		t.Fatalf("expected 0.84 tax at 7%% and 1.90 at 19%%, got %+v", price.Taxes)
+	}
+
+	// quoting neither takes seats nor stock
+	if response = quote(); !response.Available || response.SeatsLeft != hall.Capacity {
+		t.Fatalf("expected all %d seats to be left, got %d", hall.Capacity, response.SeatsLeft)
+	}
+
+	concession, err := tdb.Concession.GetConcessionByID(context.TODO(), popcorn.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if concession.Stock != 10 {
+		t.Fatalf("expected the popcorn stock to be untouched, got %d", concession.Stock)
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	uploadFormField  = "file"
+	maxImageSize     = 10 << 20
+	maxImagePixels   = 40_000_000
+	minImageSide     = 100
+	thumbnailWidth   = 320
+	thumbnailQuality = 85
+)
+
+// imageExtensions are the image types that can be uploaded.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type upload struct {
+	data        []byte
+	contentType string
+	width       int
+	height      int
+	thumbnail   []byte
+}
+
+// readImage reads and validates the image uploaded in the multipart form and
+// renders its thumbnail. The type is detected from the content, whatever the
+// client claims it to be.
+func readImage(c *fiber.Ctx) (*upload, error) {
+	header, err := c.FormFile(uploadFormField)
+	if err != nil {
+		return nil, NewError(http.StatusBadRequest, fmt.Sprintf("the image should be uploaded as the %q form field", uploadFormField))
+	}
+
+	if header.Size > maxImageSize {
+		return nil, NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("images should be at most %d MB", maxImageSize>>20))
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("images should be at most %d MB", maxImageSize>>20))
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return nil, NewError(http.StatusUnsupportedMediaType, "images should be JPEG, PNG or GIF")
+	}
+
+	// the dimensions are checked before decoding, so that small files of huge
+	// images can not exhaust the memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, NewError(http.StatusBadRequest, "the image is damaged")
+	}
+	if config.Width < minImageSide || config.Height < minImageSide {
+		return nil, NewError(http.StatusBadRequest, fmt.Sprintf("images should be at least %dx%d pixels", minImageSide, minImageSide))
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, NewError(http.StatusBadRequest, fmt.Sprintf("images should have at most %d megapixels", maxImagePixels/1_000_000))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, NewError(http.StatusBadRequest, "the image is damaged")
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, thumbnail(img, thumbnailWidth), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+
+	return &upload{
+		data:        data,
+		contentType: contentType,
+		width:       config.Width,
+		height:      config.Height,
+		thumbnail:   thumb.Bytes(),
+	}, nil
+}
+
+// thumbnail scales the image down to the width, keeping its aspect ratio.
+// Every thumbnail pixel is the average of the pixels it covers, laid over a
+// white background as JPEG has no transparency.
+func thumbnail(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			// colors are premultiplied, so white shows through by the
+			// missing alpha
+			white := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/n + white),
+				G: uint16(g/n + white),
+				B: uint16(b/n + white),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return dst
+}
+
+// storeImage saves the upload and its thumbnail under the prefix.
+func storeImage(ctx context.Context, blobs blob.Store, prefix string, up *upload) (*types.Image, error) {
+	id := primitive.NewObjectID().Hex()
+	img := &types.Image{
+		ID:           id,
+		Key:          fmt.Sprintf("%s/%s%s", prefix, id, imageExtensions[up.contentType]),
+		ThumbnailKey: fmt.Sprintf("%s/%s_thumb.jpg", prefix, id),
+		ContentType:  up.contentType,
+		Width:        up.width,
+		Height:       up.height,
+		UploadedAt:   time.Now().UTC(),
+	}
+
+	if err := blobs.Put(ctx, img.Key, up.data, up.contentType); err != nil {
+		return nil, err
+	}
+	if err := blobs.Put(ctx, img.ThumbnailKey, up.thumbnail, "image/jpeg"); err != nil {
+		if err := blobs.Delete(ctx, img.Key); err != nil {
+			fmt.Println("Error deleting image:", err)
+		}
+		return nil, err
+	}
+
+	img.URL = blobs.URL(img.Key)
+	img.ThumbnailURL = blobs.URL(img.ThumbnailKey)
+
+	return img, nil
+}
+
+// deleteImage removes the files of an image that is no longer referenced.
+// Failures are only logged, as the image is already gone from the API.
+func deleteImage(ctx context.Context, blobs blob.Store, img types.Image) {
+	for _, key := range []string{img.Key, img.ThumbnailKey} {
+		if err := blobs.Delete(ctx, key); err != nil {
+			fmt.Printf("Error deleting image %s: %s\n", key, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/importer"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type ImportHandler struct {
+	importer *importer.Importer
+}
+
+func NewImportHandler(store *db.Store) *ImportHandler {
+	return &ImportHandler{
+		importer: importer.New(store),
+	}
+}
+
+type ImportQueryParams struct {
+	// Format is csv or json. It defaults to the type of the uploaded file.
+	Format string
+	DryRun bool
+}
+
+// HandleImport imports movies, cinemas or halls from a CSV or JSON file,
+// uploaded as the "file" form field or sent as the request body. Rows that
+// fail validation are reported and skipped, the others are upserted by their
+// external ID.
+func (h *ImportHandler) HandleImport(c *fiber.Ctx) error {
+	var params ImportQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	kind := importer.Kind(c.Params("kind"))
+	if !kind.IsValid() {
+		return NewError(http.StatusBadRequest, "kind should be one of movies, cinemas, halls")
+	}
+
+	var (
+		body        io.Reader = bytes.NewReader(c.Body())
+		name        string
+		contentType = c.Get(fiber.HeaderContentType)
+	)
+	if header, err := c.FormFile(uploadFormField); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		body, name, contentType = file, header.Filename, header.Header.Get(fiber.HeaderContentType)
+	}
+
+	format := importer.Format(strings.ToLower(params.Format))
+	if len(format) == 0 {
+		format = importer.DetectFormat(name, contentType)
+	}
+
+	opts := importer.Options{
+		Kind:   kind,
+		Format: format,
+		DryRun: params.DryRun,
+	}
+	report, err := h.importer.Import(c.Context(), body, opts)
+	if err != nil {
+		if errors.Is(err, importer.ErrInvalidFile) {
+			return NewError(http.StatusBadRequest, err.Error())
+		}
+
+		return err
+	}
+
+	return c.JSON(report)
+}
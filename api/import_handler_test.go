@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/importer"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportCatalog(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser     = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		app           = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin         = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		importHandler = NewImportHandler(tdb.Store)
+	)
+
+	fixtures.AddGenre(tdb.Store, types.Drama, "Drama")
+	fixtures.AddGenre(tdb.Store, types.Thriller, "Thriller")
+
+	admin.Post("/import/:kind", importHandler.HandleImport)
+
+	send := func(target, contentType, body string) *importer.Report {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		var report importer.Report
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+
+		return &report
+	}
+
+	movies := `externalID,title,genres,runtime,releaseDate
+m-1,Heat,drama|thriller,170,1995-12-15
+m-2,Ronin,thriller,abc,
+m-1,Heat again,drama,,
+`
+	report := send("/import/movies?dryRun=true", "text/csv", movies)
+	if report.Created != 1 || report.Failed != 2 || len(report.Errors) != 2 {
+		t.Fatalf("expected 1 movie to be created and 2 rows to fail, got %+v", report)
+	}
+	if report.Errors[0].Row != 3 || len(report.Errors[0].Errors["runtime"]) == 0 {
+		t.Fatalf("expected row 3 to fail on its runtime, got %+v", report.Errors[0])
+	}
+
+	count, err := tdb.Movie.CountMovies(context.TODO(), db.Map{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("expected a dry run not to import anything, got %d movies", count)
+	}
+
+	send("/import/movies", "text/csv", movies)
+	report = send("/import/movies", "text/csv", "externalID,title,genres\nm-1,Heat (1995),drama\n")
+	if report.Created != 0 || report.Updated != 1 {
+		t.Fatalf("expected the movie to be updated, got %+v", report)
+	}
+
+	cinemas := `[{"externalID": "c-1", "name": "babylon", "city": "berlin", "street": "Rosa-Luxemburg-Str. 30", "postalCode": "10178", "lat": 52.5262, "lng": 13.4108}]`
+	if report := send("/import/cinemas", "application/json", cinemas); report.Created != 1 {
+		t.Fatalf("expected the cinema to be created, got %+v", report)
+	}
+
+	halls := "externalID,cinema,movie,capacity,price\nh-1,c-1,m-1,120,9.5\nh-2,c-9,m-1,80,8\n"
+	report = send("/import/halls", "text/csv", halls)
+	if report.Created != 1 || report.Failed != 1 || len(report.Errors[0].Errors["cinema"]) == 0 {
+		t.Fatalf("expected 1 hall to be created and 1 to fail on its cinema, got %+v", report)
+	}
+
+	movieList, err := tdb.Movie.GetMovies(context.TODO(), db.Map{"externalID": "m-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movieList) != 1 || movieList[0].Title != "Heat (1995)" {
+		t.Fatalf("expected a single updated movie, got %+v", movieList)
+	}
+
+	hallList, err := tdb.Hall.GetHalls(context.TODO(), db.Map{"externalID": "h-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hallList) != 1 || hallList[0].Movie != movieList[0].ID {
+		t.Fatalf("expected the hall to show the imported movie, got %+v", hallList)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595 // A4 in points
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 10
+	pdfLeading    = 14
+)
+
+// renderInvoicePDF lays the invoice out as a single A4 page of monospaced
+// text. It writes the PDF objects by hand, which is enough for a receipt and
+// avoids pulling in a PDF library.
+func renderInvoicePDF(invoice *types.Invoice) []byte {
+	lines := invoiceLines(invoice)
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>", pdfPageWidth, pdfPageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+func invoiceLines(invoice *types.Invoice) []string {
+	price := invoice.Price
+	lines := []string{
+		fmt.Sprintf("INVOICE %s", invoice.Number),
+		fmt.Sprintf("Issued: %s", invoice.IssuedAt.Format("2006-01-02")),
+		fmt.Sprintf("Booking: %s", invoice.BookingID.Hex()),
+		"",
+		"Seller:",
+		invoice.Seller.Name,
+		invoice.Seller.Address,
+		invoice.Seller.Country,
+		"",
+		"Customer:",
+		invoice.Customer.Name,
+		invoice.Customer.Email,
+		"",
+		fmt.Sprintf("%-40s %4s %10s %6s %10s", "Description", "Qty", "Unit", "VAT%", "Amount"),
+		strings.Repeat("-", 74),
+	}
+
+	for _, item := range price.Items {
+		lines = append(lines, fmt.Sprintf("%-40.40s %4d %10.2f %6.2f %10.2f", item.Description, item.Quantity, item.UnitPrice, item.TaxRate, item.Gross))
+	}
+
+	lines = append(lines, strings.Repeat("-", 74))
+	lines = append(lines, fmt.Sprintf("%-63s %10.2f", "Net", price.Net))
+	for _, tax := range price.Taxes {
+		lines = append(lines, fmt.Sprintf("%-63s %10.2f", fmt.Sprintf("VAT %.2f%% on %.2f", tax.Rate, tax.Net), tax.Tax))
+	}
+	lines = append(lines, fmt.Sprintf("%-63s %10.2f", fmt.Sprintf("Total (%s)", price.Currency), price.Gross))
+
+	return lines
+}
+
+// pdfEscape encodes s as a WinAnsi string literal. Characters outside of the
+// encoding are replaced with a question mark.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteString("\\200")
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
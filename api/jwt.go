@@ -36,6 +36,11 @@ func JWTAuthentication(userStore db.UserStore) fiber.Handler {
 		}
 
 		c.Context().SetUserValue("user", user)
+		// the stores only show the staff of a cinema chain its own data
+		if !user.OrganizationID.IsZero() {
+			c.Context().SetUserValue(db.TenantKey, user.OrganizationID)
+		}
+
 		return c.Next()
 	}
 }
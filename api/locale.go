@@ -0,0 +1,80 @@
+package api
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"strings"
+)
+
+// allLocales is the lang asking for the stored content with all its
+// translations, for editing them.
+const allLocales = "*"
+
+// requestLocale picks the locale of the response from the lang query
+// parameter or else the Accept-Language header, falling back to the default
+// locale when neither names a supported one. It reports false when all
+// translations were asked for.
+func requestLocale(c *fiber.Ctx) (types.Locale, bool) {
+	if lang := c.Query("lang"); len(lang) > 0 {
+		if lang == allLocales {
+			return types.DefaultLocale, false
+		}
+
+		if locale, ok := types.ParseLocale(lang); ok {
+			return locale, true
+		}
+
+		return types.DefaultLocale, true
+	}
+
+	return acceptedLocale(c.Get(fiber.HeaderAcceptLanguage)), true
+}
+
+// acceptedLocale returns the supported locale the header prefers most. Of
+// equally preferred locales the first one wins.
+func acceptedLocale(header string) types.Locale {
+	var (
+		best    = types.DefaultLocale
+		quality = 0.0
+	)
+
+	for _, lang := range strings.Split(header, ",") {
+		tag, q := lang, 1.0
+		if i := strings.IndexByte(lang, ';'); i != -1 {
+			tag = lang[:i]
+
+			param := strings.TrimSpace(lang[i+1:])
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			var err error
+			if q, err = strconv.ParseFloat(param[2:], 64); err != nil {
+				continue
+			}
+		}
+
+		if locale, ok := types.ParseLocale(tag); ok && q > quality {
+			best, quality = locale, q
+		}
+	}
+
+	return best
+}
+
+// localize translates the documents to the locale of the request and tells
+// caches that the response depends on it.
+func localize[T interface{ Localize(types.Locale) }](c *fiber.Ctx, docs ...T) {
+	c.Vary(fiber.HeaderAcceptLanguage)
+
+	locale, ok := requestLocale(c)
+	if !ok {
+		return
+	}
+
+	c.Set(fiber.HeaderContentLanguage, string(locale))
+	for _, doc := range docs {
+		doc.Localize(locale)
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"time"
+)
+
+type LoyaltyHandler struct {
+	store *db.Store
+}
+
+func NewLoyaltyHandler(store *db.Store) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		store: store,
+	}
+}
+
+type LoyaltyResponse struct {
+	Balance int                   `json:"balance"`
+	Entries []*types.LoyaltyEntry `json:"entries"`
+	Page    int                   `json:"page"`
+}
+
+func (h *LoyaltyHandler) HandleGetLoyalty(c *fiber.Ctx) error {
+	var pag db.Pagination
+	if err := c.QueryParser(&pag); err != nil {
+		return ErrBadRequest()
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	balance, err := h.store.Loyalty.GetLoyaltyBalance(c.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.store.Loyalty.GetLoyaltyEntries(c.Context(), db.Map{"userID": user.ID}, &pag)
+	if err != nil {
+		return err
+	}
+
+	resp := LoyaltyResponse{
+		Balance: balance,
+		Entries: entries,
+		Page:    int(pag.Page),
+	}
+	return c.JSON(resp)
+}
+
+func (h *LoyaltyHandler) HandleGetProgram(c *fiber.Ctx) error {
+	program, err := h.store.Loyalty.GetLoyaltyProgram(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(program)
+}
+
+func (h *LoyaltyHandler) HandlePutProgram(c *fiber.Ctx) error {
+	var program types.LoyaltyProgram
+	if err := c.BodyParser(&program); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := program.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	if program.SessionBonuses == nil {
+		program.SessionBonuses = []types.SessionBonus{}
+	}
+
+	if err := h.store.Loyalty.UpdateLoyaltyProgram(c.Context(), &program); err != nil {
+		return err
+	}
+
+	return c.JSON(program)
+}
+
+// accrueLoyaltyPoints credits the points for the money collected with the
+// given payments of a booking. Payments that are not captured yet earn
+// nothing until they are. The session bonus is credited once the whole
+// booking is paid for, and never for tickets paid with points or a pass.
+func accrueLoyaltyPoints(ctx context.Context, store *db.Store, booking *types.Booking, payments []types.Payment) error {
+	var amountPaid float64
+	for _, payment := range payments {
+		if payment.Method == types.PaymentLoyalty || payment.Method == types.PaymentPass || !payment.IsCaptured() {
+			continue
+		}
+		amountPaid += payment.Amount
+	}
+
+	paidTicket := true
+	for _, payment := range booking.Payments {
+		if payment.Method == types.PaymentLoyalty || payment.Method == types.PaymentPass || !payment.IsCaptured() {
+			paidTicket = false
+		}
+	}
+
+	program, err := store.Loyalty.GetLoyaltyProgram(ctx)
+	if err != nil {
+		return err
+	}
+
+	points, bonus := program.EarnedPoints(booking.Session, amountPaid)
+	if !paidTicket {
+		bonus = 0
+	}
+	entries := []*types.LoyaltyEntry{
+		{Kind: types.LoyaltyEarn, Points: points, Description: fmt.Sprintf("Points for booking %s", booking.ID.Hex())},
+		{Kind: types.LoyaltyBonus, Points: bonus, Description: fmt.Sprintf("Session bonus for booking %s", booking.ID.Hex())},
+	}
+	for _, entry := range entries {
+		if entry.Points <= 0 {
+			continue
+		}
+
+		entry.UserID = booking.UserID
+		entry.BookingID = booking.ID
+		entry.CreatedAt = time.Now().UTC()
+		if _, err := store.Loyalty.InsertLoyaltyEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func redeemLoyaltyPoints(ctx context.Context, store *db.Store, user *types.User, booking *types.Booking) (*types.LoyaltyEntry, error) {
+	program, err := store.Loyalty.GetLoyaltyProgram(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &types.LoyaltyEntry{
+		UserID:      user.ID,
+		Kind:        types.LoyaltyRedeem,
+		Points:      -program.TicketCost,
+		BookingID:   booking.ID,
+		Description: fmt.Sprintf("Ticket for booking %s", booking.ID.Hex()),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	// the balance is checked when the points are taken
+	entry, err = store.Loyalty.InsertLoyaltyEntry(ctx, entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NewError(http.StatusBadRequest, fmt.Sprintf("%d loyalty points are needed for a ticket", program.TicketCost))
+	}
+
+	return entry, err
+}
+
+// reverseLoyaltyPoints undoes every points movement of a booking, taking
+// back earned points and restoring redeemed ones.
+func reverseLoyaltyPoints(ctx context.Context, store *db.Store, booking *types.Booking) error {
+	points, err := store.Loyalty.SumLoyaltyPoints(ctx, db.Map{"bookingID": booking.ID})
+	if err != nil {
+		return err
+	}
+	if points == 0 {
+		return nil
+	}
+
+	entry := &types.LoyaltyEntry{
+		UserID:      booking.UserID,
+		Kind:        types.LoyaltyReversal,
+		Points:      -points,
+		BookingID:   booking.ID,
+		Description: fmt.Sprintf("Reversal for canceled booking %s", booking.ID.Hex()),
+		CreatedAt:   time.Now().UTC(),
+	}
+	_, err = store.Loyalty.InsertLoyaltyEntry(ctx, entry)
+
+	return err
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+)
+
+const (
+	maxMovieStills    = 20
+	maxCinemaPhotos   = 20
+	movieMediaPrefix  = "movies"
+	cinemaMediaPrefix = "cinemas"
+)
+
+type MediaHandler struct {
+	store *db.Store
+	blobs blob.Store
+}
+
+func NewMediaHandler(store *db.Store, blobs blob.Store) *MediaHandler {
+	return &MediaHandler{
+		store: store,
+		blobs: blobs,
+	}
+}
+
+// HandlePostMoviePoster uploads the poster of a movie, replacing the previous
+// one.
+func (h *MediaHandler) HandlePostMoviePoster(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	movie, err := h.store.Movie.GetMovieByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("movie")
+	}
+
+	up, err := readImage(c)
+	if err != nil {
+		return err
+	}
+
+	poster, err := storeImage(c.Context(), h.blobs, movieMediaPrefix+"/"+id, up)
+	if err != nil {
+		return err
+	}
+
+	update := db.Map{"poster": poster, "posterURL": poster.URL}
+	if err := h.store.Movie.UpdateMovie(c.Context(), id, update); err != nil {
+		deleteImage(c.Context(), h.blobs, *poster)
+		return err
+	}
+
+	if movie.Poster != nil {
+		deleteImage(c.Context(), h.blobs, *movie.Poster)
+	}
+
+	return c.JSON(poster)
+}
+
+func (h *MediaHandler) HandlePostMovieStill(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	movie, err := h.store.Movie.GetMovieByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("movie")
+	}
+	if len(movie.Stills) >= maxMovieStills {
+		return NewError(http.StatusConflict, fmt.Sprintf("movies can have at most %d stills", maxMovieStills))
+	}
+
+	up, err := readImage(c)
+	if err != nil {
+		return err
+	}
+
+	still, err := storeImage(c.Context(), h.blobs, movieMediaPrefix+"/"+id, up)
+	if err != nil {
+		return err
+	}
+
+	if err := h.store.Movie.AddMovieStill(c.Context(), objID, *still, maxMovieStills); err != nil {
+		deleteImage(c.Context(), h.blobs, *still)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusConflict, fmt.Sprintf("movies can have at most %d stills", maxMovieStills))
+		}
+
+		return err
+	}
+
+	return c.JSON(still)
+}
+
+func (h *MediaHandler) HandleDeleteMovieStill(c *fiber.Ctx) error {
+	var (
+		id      = c.Params("id")
+		imageID = c.Params("imageID")
+	)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	movie, err := h.store.Movie.GetMovieByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("movie")
+	}
+
+	for _, still := range movie.Stills {
+		if still.ID != imageID {
+			continue
+		}
+
+		if err := h.store.Movie.RemoveMovieStill(c.Context(), objID, imageID); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrResourceNotFound("still")
+			}
+
+			return err
+		}
+		deleteImage(c.Context(), h.blobs, still)
+
+		return c.JSON(map[string]string{"deleted": imageID})
+	}
+
+	return ErrResourceNotFound("still")
+}
+
+func (h *MediaHandler) HandlePostCinemaPhoto(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("cinema")
+	}
+	if len(cinema.Photos) >= maxCinemaPhotos {
+		return NewError(http.StatusConflict, fmt.Sprintf("cinemas can have at most %d photos", maxCinemaPhotos))
+	}
+
+	up, err := readImage(c)
+	if err != nil {
+		return err
+	}
+
+	photo, err := storeImage(c.Context(), h.blobs, cinemaMediaPrefix+"/"+id, up)
+	if err != nil {
+		return err
+	}
+
+	if err := h.store.Cinema.AddCinemaPhoto(c.Context(), objID, *photo, maxCinemaPhotos); err != nil {
+		deleteImage(c.Context(), h.blobs, *photo)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusConflict, fmt.Sprintf("cinemas can have at most %d photos", maxCinemaPhotos))
+		}
+
+		return err
+	}
+
+	return c.JSON(photo)
+}
+
+func (h *MediaHandler) HandleDeleteCinemaPhoto(c *fiber.Ctx) error {
+	var (
+		id      = c.Params("id")
+		imageID = c.Params("imageID")
+	)
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("cinema")
+	}
+
+	for _, photo := range cinema.Photos {
+		if photo.ID != imageID {
+			continue
+		}
+
+		if err := h.store.Cinema.RemoveCinemaPhoto(c.Context(), objID, imageID); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrResourceNotFound("photo")
+			}
+
+			return err
+		}
+		deleteImage(c.Context(), h.blobs, photo)
+
+		return c.JSON(map[string]string{"deleted": imageID})
+	}
+
+	return ErrResourceNotFound("photo")
+}
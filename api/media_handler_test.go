@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPostMoviePoster(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	dir := t.TempDir()
+	blobs, err := blob.NewLocalStore(dir, "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		adminUser    = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		movie        = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		app          = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin        = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		mediaHandler = NewMediaHandler(tdb.Store, blobs)
+	)
+
+	admin.Post("/movie/:id/poster", mediaHandler.HandlePostMoviePoster)
+
+	upload := func(data []byte) *http.Response {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "poster.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+		form.Close()
+
+		req := httptest.NewRequest("POST", "/movie/"+movie.ID.Hex()+"/poster", &body)
+		req.Header.Add("Content-Type", form.FormDataContentType())
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	resp := upload([]byte("definitely not an image"))
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected status code %d for a text file, got %d", http.StatusUnsupportedMediaType, resp.StatusCode)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 800, 1200))
+	for y := 0; y < 1200; y++ {
+		for x := 0; x < 800; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, img); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = upload(poster.Bytes())
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var uploaded types.Image
+	if err := json.NewDecoder(resp.Body).Decode(&uploaded); err != nil {
+		t.Fatal(err)
+	}
+	if uploaded.ContentType != "image/png" || uploaded.Width != 800 || uploaded.Height != 1200 {
+		t.Fatalf("expected an 800x1200 PNG, got %+v", uploaded)
+	}
+
+	updated, err := tdb.Movie.GetMovieByID(context.TODO(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.PosterURL != uploaded.URL || updated.Poster == nil {
+		t.Fatalf("expected the poster URL to be %s, got %s", uploaded.URL, updated.PosterURL)
+	}
+
+	file, err := os.Open(filepath.Join(dir, filepath.FromSlash(updated.Poster.ThumbnailKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	config, err := jpeg.DecodeConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != thumbnailWidth || config.Height != 480 {
+		t.Fatalf("expected a %dx480 thumbnail, got %dx%d", thumbnailWidth, config.Width, config.Height)
+	}
+}
@@ -2,18 +2,30 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
 )
 
+const dateLayout = "2006-01-02"
+
 type MovieHandler struct {
 	store *db.Store
+	blobs blob.Store
 }
 
-func NewMovieHandler(store *db.Store) *MovieHandler {
+func NewMovieHandler(store *db.Store, blobs blob.Store) *MovieHandler {
 	return &MovieHandler{
 		store: store,
+		blobs: blobs,
 	}
 }
 
@@ -28,14 +40,275 @@ func (h *MovieHandler) HandleGetMovie(c *fiber.Ctx) error {
 		return err
 	}
 
+	localize(c, movie)
+
 	return c.JSON(movie)
 }
 
+type MovieQueryParams struct {
+	db.ListOptions
+	// Genre is a comma separated list of genres, movies with any of them
+	// match.
+	Genre          string
+	Title          string
+	Director       string
+	Cast           string
+	Language       string
+	Audio          string
+	Subtitle       string
+	Certification  string
+	MinRuntime     int
+	MaxRuntime     int
+	ReleasedAfter  string
+	ReleasedBefore string
+}
+
+// filter turns the query into a movie filter. Text fields match
+// case-insensitively, title, director and cast by substring.
+func (p MovieQueryParams) filter() (db.Map, error) {
+	filter := db.Map{}
+
+	if len(p.Genre) > 0 {
+		genres := []types.Genre{}
+		for _, genre := range strings.Split(p.Genre, ",") {
+			genres = append(genres, types.Genre(strings.ToLower(strings.TrimSpace(genre))))
+		}
+		filter["genres"] = db.Map{"$in": genres}
+	}
+
+	if len(p.Title) > 0 {
+		filter["title"] = containsFold(p.Title)
+	}
+
+	if len(p.Director) > 0 {
+		filter["director"] = containsFold(p.Director)
+	}
+
+	if len(p.Cast) > 0 {
+		filter["cast"] = containsFold(p.Cast)
+	}
+
+	if len(p.Language) > 0 {
+		filter["originalLanguage"] = strings.ToLower(p.Language)
+	}
+
+	if len(p.Audio) > 0 {
+		filter["audioLanguages"] = strings.ToLower(p.Audio)
+	}
+
+	if len(p.Subtitle) > 0 {
+		filter["subtitleLanguages"] = strings.ToLower(p.Subtitle)
+	}
+
+	if len(p.Certification) > 0 {
+		filter["certification"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(p.Certification) + "$", Options: "i"}
+	}
+
+	runtime := db.Map{}
+	if p.MinRuntime > 0 {
+		runtime["$gte"] = p.MinRuntime
+	}
+	if p.MaxRuntime > 0 {
+		runtime["$lte"] = p.MaxRuntime
+	}
+	if len(runtime) > 0 {
+		filter["runtime"] = runtime
+	}
+
+	released := db.Map{}
+	if len(p.ReleasedAfter) > 0 {
+		after, err := time.Parse(dateLayout, p.ReleasedAfter)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, "releasedAfter should be a date like 2006-01-02")
+		}
+		released["$gte"] = after
+	}
+	if len(p.ReleasedBefore) > 0 {
+		before, err := time.Parse(dateLayout, p.ReleasedBefore)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, "releasedBefore should be a date like 2006-01-02")
+		}
+		released["$lt"] = before.AddDate(0, 0, 1)
+	}
+	if len(released) > 0 {
+		filter["releaseDate"] = released
+	}
+
+	return filter, nil
+}
+
+func containsFold(s string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(s), Options: "i"}
+}
+
 func (h *MovieHandler) HandleGetMovies(c *fiber.Ctx) error {
-	movies, err := h.store.Movie.GetMovies(c.Context(), db.Map{})
+	var params MovieQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if err := checkSort(params.Sort, "title", "releaseDate", "runtime"); err != nil {
+		return err
+	}
+	if len(params.Sort) == 0 {
+		params.Sort = "title"
+	}
+
+	filter, err := params.filter()
+	if err != nil {
+		return err
+	}
+
+	movies, err := h.store.Movie.GetMovies(c.Context(), filter, &params.ListOptions)
 	if err != nil {
 		return ErrResourceNotFound("movie")
 	}
 
-	return c.JSON(movies)
+	total, err := h.store.Movie.CountMovies(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	localize(c, movies...)
+
+	resp := ResourceResponse{
+		Results: len(movies),
+		Data:    movies,
+		Page:    int(params.Page),
+		Total:   total,
+	}
+	return c.JSON(resp)
+}
+
+func (h *MovieHandler) HandlePostMovie(c *fiber.Ctx) error {
+	var params types.CreateMovieParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	if err := checkGenres(c.Context(), h.store, params.Genres); err != nil {
+		return err
+	}
+
+	movie, err := h.store.Movie.InsertMovie(c.Context(), types.NewMovieFromParams(params))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(movie)
+}
+
+func (h *MovieHandler) HandlePutMovie(c *fiber.Ctx) error {
+	var (
+		params types.UpdateMovieParams
+		id     = c.Params("id")
+	)
+
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	update := db.Map(params.ToBSON())
+	if len(update) == 0 {
+		return NewError(http.StatusBadRequest, "nothing to update")
+	}
+
+	if err := checkGenres(c.Context(), h.store, params.Genres); err != nil {
+		return err
+	}
+
+	if err := h.store.Movie.UpdateMovie(c.Context(), id, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("movie")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"updated": id})
+}
+
+// HandleDeleteMovie refuses to delete movies that are still scheduled in a
+// hall or have upcoming bookings, since those would be left dangling.
+func (h *MovieHandler) HandleDeleteMovie(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	movieID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	halls, err := h.store.Hall.CountHalls(c.Context(), db.Map{"movie": movieID})
+	if err != nil {
+		return err
+	}
+	if halls > 0 {
+		return NewError(http.StatusConflict, fmt.Sprintf("movie is still shown in %d hall(s)", halls))
+	}
+
+	bookings, err := h.store.Booking.CountBookings(c.Context(), db.Map{
+		"movieID":  movieID,
+		"canceled": false,
+		"date":     db.Map{"$gte": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if bookings > 0 {
+		return NewError(http.StatusConflict, fmt.Sprintf("movie has %d upcoming booking(s)", bookings))
+	}
+
+	showtimes, err := h.store.Showtime.CountShowtimes(c.Context(), db.Map{
+		"movieID":  movieID,
+		"startsAt": db.Map{"$gte": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if showtimes > 0 {
+		return NewError(http.StatusConflict, fmt.Sprintf("movie has %d upcoming showtime(s)", showtimes))
+	}
+
+	movie, err := h.store.Movie.GetMovieByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("movie")
+		}
+
+		return err
+	}
+
+	if err := h.store.Movie.DeleteMovie(c.Context(), id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("movie")
+		}
+
+		return err
+	}
+
+	// only schedules that ran out are left
+	if _, err := clearProgramme(c.Context(), h.store, db.Map{"movieID": movieID}); err != nil {
+		return err
+	}
+
+	if movie.Poster != nil {
+		deleteImage(c.Context(), h.blobs, *movie.Poster)
+	}
+	for _, still := range movie.Stills {
+		deleteImage(c.Context(), h.blobs, still)
+	}
+
+	return c.JSON(map[string]string{"deleted": id})
 }
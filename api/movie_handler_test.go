@@ -0,0 +1,286 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAdminMovieCRUD(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser    = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		cinema       = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		app          = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin        = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		movieHandler = NewMovieHandler(tdb.Store, tdb.blobs)
+		token        = CreateTokenFromUser(adminUser)
+	)
+
+	fixtures.AddGenre(tdb.Store, types.Horror, "Horror")
+	fixtures.AddGenre(tdb.Store, types.Thriller, "Thriller")
+
+	admin.Post("/movie", movieHandler.HandlePostMovie)
+	admin.Put("/movie/:id", movieHandler.HandlePutMovie)
+	admin.Delete("/movie/:id", movieHandler.HandleDeleteMovie)
+
+	send := func(method, target string, body any) *http.Response {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	resp := send("POST", "/movie", types.CreateMovieParams{Title: ""})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d for an invalid movie, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	resp = send("POST", "/movie", types.CreateMovieParams{Title: "the lighthouse", Genres: []types.Genre{"western"}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code %d for a genre that is not in the catalog, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	resp = send("POST", "/movie", types.CreateMovieParams{Title: "the lighthouse", Genres: []types.Genre{types.Horror}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var movie types.Movie
+	if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = send("PUT", "/movie/"+movie.ID.Hex(), types.UpdateMovieParams{Title: "The Lighthouse"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	updated, err := tdb.Movie.GetMovieByID(context.TODO(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "The Lighthouse" || len(updated.Genres) != 1 || updated.Genres[0] != types.Horror {
+		t.Fatalf("expected only the title to be updated, got %+v", updated)
+	}
+
+	// movies shown in a hall can not be deleted
+	fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+	resp = send("DELETE", "/movie/"+movie.ID.Hex(), nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	// neither can movies with upcoming showtimes
+	scheduled := fixtures.AddMovie(tdb.Store, "hereditary", types.Horror)
+	startsAt := time.Now().Add(48 * time.Hour)
+	showtime := &types.Showtime{MovieID: scheduled.ID, CinemaID: cinema.ID, StartsAt: startsAt, EndsAt: startsAt.Add(2 * time.Hour)}
+	if err := tdb.Showtime.InsertShowtimes(context.TODO(), []*types.Showtime{showtime}); err != nil {
+		t.Fatal(err)
+	}
+	resp = send("DELETE", "/movie/"+scheduled.ID.Hex(), nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d for a movie with showtimes, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	unused := fixtures.AddMovie(tdb.Store, "midsommar", types.Horror)
+	still := types.Image{ID: "still", Key: "movies/midsommar/still.jpg", ThumbnailKey: "movies/midsommar/still_thumb.jpg"}
+	for _, key := range []string{still.Key, still.ThumbnailKey} {
+		if err := tdb.blobs.Put(context.TODO(), key, []byte("jpeg"), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tdb.Movie.AddMovieStill(context.TODO(), unused.ID, still, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = send("DELETE", "/movie/"+unused.ID.Hex(), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	// the files of the stills go with the movie
+	for _, key := range []string{still.Key, still.ThumbnailKey} {
+		if _, err := os.Stat(filepath.Join(tdb.blobs.Dir(), key)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be deleted, got %v", key, err)
+		}
+	}
+}
+
+func TestGetMoviesByMetadata(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user         = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		app          = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1        = app.Group("/", JWTAuthentication(tdb.User))
+		movieHandler = NewMovieHandler(tdb.Store, tdb.blobs)
+	)
+
+	apiV1.Get("/movie", movieHandler.HandleGetMovies)
+
+	movies := []*types.Movie{
+		{
+			Title:  "The Dark Knight",
+			Genres: []types.Genre{types.Action, types.Thriller},
+			MovieMetadata: types.MovieMetadata{
+				Runtime:        152,
+				ReleaseDate:    time.Date(2008, 7, 18, 0, 0, 0, 0, time.UTC),
+				Director:       "Christopher Nolan",
+				Cast:           []string{"Christian Bale", "Heath Ledger"},
+				AudioLanguages: []string{"en", "de"},
+			},
+		},
+		{
+			Title:  "Memento",
+			Genres: []types.Genre{types.Thriller},
+			MovieMetadata: types.MovieMetadata{
+				Runtime:        113,
+				ReleaseDate:    time.Date(2000, 9, 5, 0, 0, 0, 0, time.UTC),
+				Director:       "Christopher Nolan",
+				Cast:           []string{"Guy Pearce"},
+				AudioLanguages: []string{"en"},
+			},
+		},
+	}
+	for _, movie := range movies {
+		if _, err := tdb.Movie.InsertMovie(context.TODO(), movie); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(query string) []types.Movie {
+		req := httptest.NewRequest("GET", "/movie?"+query, nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d for %q, got %d", http.StatusOK, query, resp.StatusCode)
+		}
+
+		var found []types.Movie
+		response := ResourceResponse{Data: &found}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Total != len(found) {
+			t.Fatalf("expected a total of %d, got %d", len(found), response.Total)
+		}
+
+		return found
+	}
+
+	if found := get("director=nolan&minRuntime=120"); len(found) != 1 || found[0].Title != "The Dark Knight" {
+		t.Fatalf("expected only The Dark Knight, got %+v", found)
+	}
+
+	if found := get("cast=ledger&audio=DE"); len(found) != 1 || found[0].Title != "The Dark Knight" {
+		t.Fatalf("expected only The Dark Knight, got %+v", found)
+	}
+
+	if found := get("genre=action,comedy"); len(found) != 1 || found[0].Title != "The Dark Knight" {
+		t.Fatalf("expected only The Dark Knight, got %+v", found)
+	}
+
+	if found := get("genre=thriller"); len(found) != 2 {
+		t.Fatalf("expected 2 thrillers, got %+v", found)
+	}
+
+	if found := get("director=nolan&sort=-runtime"); len(found) != 2 || found[0].Title != "The Dark Knight" {
+		t.Fatalf("expected the longest movie first, got %+v", found)
+	}
+
+	if found := get("releasedBefore=2000-09-05"); len(found) != 1 || found[0].Title != "Memento" {
+		t.Fatalf("expected only Memento, got %+v", found)
+	}
+}
+
+func TestGetMovieLocalized(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user         = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		movie        = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		app          = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1        = app.Group("/", JWTAuthentication(tdb.User))
+		movieHandler = NewMovieHandler(tdb.Store, tdb.blobs)
+	)
+
+	apiV1.Get("/movie/:id", movieHandler.HandleGetMovie)
+
+	update := types.UpdateMovieParams{
+		Translations: map[types.Locale]types.MovieTranslation{
+			types.LocaleGerman:  {Title: "der leuchtturm"},
+			types.LocaleTurkish: {Title: "deniz feneri"},
+		},
+	}
+	if err := tdb.Movie.UpdateMovie(context.TODO(), movie.ID.Hex(), db.Map(update.ToBSON())); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(target, acceptLanguage string) (*http.Response, types.Movie) {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+		if len(acceptLanguage) > 0 {
+			req.Header.Add("Accept-Language", acceptLanguage)
+		}
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var movie types.Movie
+		if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
+			t.Fatal(err)
+		}
+
+		return resp, movie
+	}
+
+	target := "/movie/" + movie.ID.Hex()
+
+	resp, localized := get(target, "fr-FR, de-DE;q=0.8, tr;q=0.5")
+	if localized.Title != "der leuchtturm" || localized.Translations != nil {
+		t.Fatalf("expected the german title only, got %+v", localized)
+	}
+	if lang := resp.Header.Get("Content-Language"); lang != "de" {
+		t.Fatalf("expected content language de, got %q", lang)
+	}
+
+	if _, localized = get(target+"?lang=tr", "de"); localized.Title != "deniz feneri" {
+		t.Fatalf("expected lang to take precedence, got %q", localized.Title)
+	}
+
+	if _, localized = get(target+"?lang=fr", ""); localized.Title != "the lighthouse" {
+		t.Fatalf("expected the default title for an unsupported locale, got %q", localized.Title)
+	}
+
+	if _, localized = get(target+"?lang=*", ""); localized.Title != "the lighthouse" || len(localized.Translations) != 2 {
+		t.Fatalf("expected the stored movie with all translations, got %+v", localized)
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+)
+
+type OrganizationHandler struct {
+	store *db.Store
+}
+
+func NewOrganizationHandler(store *db.Store) *OrganizationHandler {
+	return &OrganizationHandler{
+		store: store,
+	}
+}
+
+func (h *OrganizationHandler) HandleGetOrganizations(c *fiber.Ctx) error {
+	var params db.ListOptions
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if err := checkSort(params.Sort, "name", "createdAt"); err != nil {
+		return err
+	}
+	if len(params.Sort) == 0 {
+		params.Sort = "name"
+	}
+
+	orgs, err := h.store.Organization.GetOrganizations(c.Context(), &params)
+	if err != nil {
+		return err
+	}
+
+	total, err := h.store.Organization.CountOrganizations(c.Context())
+	if err != nil {
+		return err
+	}
+
+	resp := ResourceResponse{
+		Results: len(orgs),
+		Data:    orgs,
+		Page:    int(params.Page),
+		Total:   total,
+	}
+	return c.JSON(resp)
+}
+
+func (h *OrganizationHandler) HandleGetOrganization(c *fiber.Ctx) error {
+	orgID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	org, err := h.store.Organization.GetOrganizationByID(c.Context(), orgID)
+	if err != nil {
+		return ErrResourceNotFound("organization")
+	}
+
+	return c.JSON(org)
+}
+
+func (h *OrganizationHandler) HandlePostOrganization(c *fiber.Ctx) error {
+	var params types.CreateOrganizationParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	org, err := h.store.Organization.InsertOrganization(c.Context(), types.NewOrganizationFromParams(params))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return NewError(http.StatusConflict, "an organization with this name already exists")
+		}
+
+		return err
+	}
+
+	return c.JSON(org)
+}
+
+// HandlePostOrganizationAdmin makes a user an admin of the organization.
+// Users administer at most one organization, and platform admins can not be
+// turned into the admins of a single one.
+func (h *OrganizationHandler) HandlePostOrganizationAdmin(c *fiber.Ctx) error {
+	orgID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	var params types.AddOrganizationAdminParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	userID, err := primitive.ObjectIDFromHex(params.UserID)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if _, err := h.store.Organization.GetOrganizationByID(c.Context(), orgID); err != nil {
+		return ErrResourceNotFound("organization")
+	}
+
+	user, err := h.store.User.GetUserByID(c.Context(), params.UserID)
+	if err != nil {
+		return ErrResourceNotFound("user")
+	}
+	if user.IsPlatformAdmin() {
+		return NewError(http.StatusConflict, "platform admins can not be limited to an organization")
+	}
+	if !user.OrganizationID.IsZero() && user.OrganizationID != orgID {
+		return NewError(http.StatusConflict, "user already belongs to another organization")
+	}
+
+	if err := h.store.User.SetOrganizationAdmin(c.Context(), userID, orgID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("user")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"organization": orgID.Hex(), "admin": userID.Hex()})
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOrganizationIsolation(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		platformAdmin       = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		chainAdmin          = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		app                 = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1               = app.Group("/", JWTAuthentication(tdb.User))
+		admin               = apiV1.Group("/admin", AdminAuth)
+		cinemaHandler       = NewCinemaHandler(tdb.Store, tdb.blobs)
+		genreHandler        = NewGenreHandler(tdb.Store)
+		organizationHandler = NewOrganizationHandler(tdb.Store)
+	)
+
+	apiV1.Get("/cinema", cinemaHandler.HandleGetCinemas)
+	apiV1.Get("/cinema/:id", cinemaHandler.HandleGetCinema)
+	admin.Post("/cinema", cinemaHandler.HandlePostCinema)
+	admin.Post("/genre", PlatformAdminAuth, genreHandler.HandlePostGenre)
+	admin.Post("/organization", PlatformAdminAuth, organizationHandler.HandlePostOrganization)
+	admin.Post("/organization/:id/admins", PlatformAdminAuth, organizationHandler.HandlePostOrganizationAdmin)
+
+	send := func(user *types.User, method, target string, body any) *http.Response {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	var org types.Organization
+	resp := send(platformAdmin, "POST", "/admin/organization", types.CreateOrganizationParams{Name: "yorck"})
+	if err := json.NewDecoder(resp.Body).Decode(&org); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = send(platformAdmin, "POST", "/admin/organization", types.CreateOrganizationParams{Name: "Yorck"})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d for a duplicate name, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	resp = send(platformAdmin, "POST", "/admin/organization/"+org.ID.Hex()+"/admins", types.AddOrganizationAdminParams{UserID: chainAdmin.ID.Hex()})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	other, err := tdb.Organization.InsertOrganization(context.TODO(), &types.Organization{Name: "cineplex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	competitor, err := tdb.Cinema.InsertCinema(context.TODO(), &types.Cinema{Name: "cineplex alhambra", Location: "berlin", OrganizationID: other.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cinema types.Cinema
+	resp = send(chainAdmin, "POST", "/admin/cinema", types.CreateCinemaParams{Name: "babylon", Location: "berlin", OrganizationID: other.ID.Hex()})
+	if err := json.NewDecoder(resp.Body).Decode(&cinema); err != nil {
+		t.Fatal(err)
+	}
+	if cinema.OrganizationID != org.ID {
+		t.Fatalf("expected the cinema to belong to the organization of its admin, got %s", cinema.OrganizationID.Hex())
+	}
+
+	resp = send(chainAdmin, "GET", "/cinema?page=1&limit=10", nil)
+	var cinemas []types.Cinema
+	if err := json.NewDecoder(resp.Body).Decode(&ResourceResponse{Data: &cinemas}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cinemas) != 1 || cinemas[0].ID != cinema.ID {
+		t.Fatalf("expected only the cinema of the organization, got %+v", cinemas)
+	}
+
+	resp = send(chainAdmin, "GET", "/cinema/"+competitor.ID.Hex(), nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status code %d for the cinema of another organization, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	resp = send(platformAdmin, "GET", "/cinema?page=1&limit=10", nil)
+	cinemas = nil
+	if err := json.NewDecoder(resp.Body).Decode(&ResourceResponse{Data: &cinemas}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cinemas) != 2 {
+		t.Fatalf("expected platform admins to see all cinemas, got %d", len(cinemas))
+	}
+
+	resp = send(chainAdmin, "POST", "/admin/genre", types.CreateGenreParams{ID: "western", Name: "Western"})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status code %d for a shared catalog change, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	giftCardRetries = 3
+	walletRetries   = 3
+)
+
+// chargeBooking settles the gross price of the booking with a pass, loyalty
+// points or stored value, gift card before wallet, and leaves whatever remains
+// to the payment gateway. When a step fails, everything charged so far is
+// given back.
+func chargeBooking(ctx context.Context, store *db.Store, user *types.User, booking *types.Booking, params BookHallParams) error {
+	booking.Payments = []types.Payment{}
+	due := booking.Price.Gross
+	currency := booking.Price.Currency
+
+	// passes and points only pay for the ticket, add-ons are paid with money
+	ticket := booking.Price.GrossOf(types.ProductTicket)
+	if params.UsePass {
+		sub, err := chargeToPass(ctx, store, user, booking)
+		if err != nil {
+			return err
+		}
+
+		booking.Payments = append(booking.Payments, types.Payment{
+			Method:    types.PaymentPass,
+			Amount:    ticket,
+			Status:    types.PaymentCaptured,
+			Reference: sub.ID.Hex(),
+		})
+		due = types.RoundMoney(due - ticket)
+	} else if params.RedeemPoints {
+		entry, err := redeemLoyaltyPoints(ctx, store, user, booking)
+		if err != nil {
+			return err
+		}
+
+		booking.Payments = append(booking.Payments, types.Payment{
+			Method:    types.PaymentLoyalty,
+			Amount:    ticket,
+			Status:    types.PaymentCaptured,
+			Reference: entry.ID.Hex(),
+		})
+		due = types.RoundMoney(due - ticket)
+	}
+
+	if len(params.GiftCardCode) > 0 && due > 0 {
+		code := types.NormalizeGiftCardCode(params.GiftCardCode)
+		amount, err := redeemGiftCard(ctx, store, code, currency, due)
+		if err != nil {
+			if err := reversePayments(ctx, store, booking); err != nil {
+				return err
+			}
+
+			return err
+		}
+
+		booking.Payments = append(booking.Payments, types.Payment{
+			Method:    types.PaymentGiftCard,
+			Amount:    amount,
+			Status:    types.PaymentCaptured,
+			Reference: code,
+		})
+		due = types.RoundMoney(due - amount)
+	}
+
+	if params.UseWallet && due > 0 {
+		entry, err := debitWallet(ctx, store, user, booking, due)
+		if err != nil {
+			if err := reversePayments(ctx, store, booking); err != nil {
+				return err
+			}
+
+			return err
+		}
+
+		if entry != nil {
+			amount := -entry.Amount
+			booking.Payments = append(booking.Payments, types.Payment{
+				Method:    types.PaymentWallet,
+				Amount:    amount,
+				Status:    types.PaymentCaptured,
+				Reference: entry.ID.Hex(),
+			})
+			due = types.RoundMoney(due - amount)
+		}
+	}
+
+	if due > 0 {
+		booking.Payments = append(booking.Payments, types.Payment{
+			Method: types.PaymentGateway,
+			Amount: due,
+			Status: types.PaymentPending,
+		})
+	}
+
+	return nil
+}
+
+func redeemGiftCard(ctx context.Context, store *db.Store, code, currency string, due float64) (float64, error) {
+	for i := 0; i < giftCardRetries; i++ {
+		card, err := store.GiftCard.GetGiftCardByCode(ctx, code)
+		if err != nil {
+			return 0, ErrResourceNotFound("gift card")
+		}
+
+		if !card.IsRedeemable(time.Now()) {
+			return 0, NewError(http.StatusBadRequest, "gift card can not be redeemed")
+		}
+
+		if card.Currency != currency {
+			return 0, NewError(http.StatusBadRequest, fmt.Sprintf("gift card is not valid for payments in %s", currency))
+		}
+
+		amount := math.Min(card.Balance, due)
+		err = store.GiftCard.UpdateGiftCardBalance(ctx, card, types.RoundMoney(card.Balance-amount))
+		if err == nil {
+			return amount, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, err
+		}
+	}
+
+	return 0, NewError(http.StatusConflict, "gift card is being redeemed concurrently")
+}
+
+// debitWallet pays as much of the amount due as the user's wallet covers.
+// The debit is checked against the balance when it is applied, so a payment
+// made concurrently makes it retry with what is left. It returns nil when the
+// wallet is empty.
+func debitWallet(ctx context.Context, store *db.Store, user *types.User, booking *types.Booking, due float64) (*types.WalletEntry, error) {
+	currency := booking.Price.Currency
+	for i := 0; i < walletRetries; i++ {
+		balances, err := store.Wallet.GetWalletBalances(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		amount := math.Min(balances[currency], due)
+		if amount <= 0 {
+			return nil, nil
+		}
+
+		entry := &types.WalletEntry{
+			UserID:      user.ID,
+			Kind:        types.WalletPayment,
+			Amount:      -amount,
+			Currency:    currency,
+			BookingID:   booking.ID,
+			Description: fmt.Sprintf("Payment for booking %s", booking.ID.Hex()),
+			CreatedAt:   time.Now().UTC(),
+		}
+		entry, err = store.Wallet.InsertWalletEntry(ctx, entry)
+		if err == nil {
+			return entry, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return nil, NewError(http.StatusConflict, "wallet is being charged concurrently")
+}
+
+// reversePayments gives stored value back to where it came from. It is used
+// when a booking could not be completed after it has been charged. Every
+// payment is reversed even if another one fails, the failures are returned
+// together so that the request fails instead of silently keeping the money.
+func reversePayments(ctx context.Context, store *db.Store, booking *types.Booking) error {
+	var failed []string
+	for _, payment := range booking.Payments {
+		switch payment.Method {
+		case types.PaymentGiftCard:
+			if err := restoreGiftCard(ctx, store, payment.Reference, payment.Amount); err != nil {
+				failed = append(failed, fmt.Sprintf("restoring gift card %s: %s", payment.Reference, err))
+			}
+		case types.PaymentPass:
+			if err := returnPassTicket(ctx, store, booking, payment); err != nil {
+				failed = append(failed, fmt.Sprintf("returning ticket of pass %s: %s", payment.Reference, err))
+			}
+		case types.PaymentLoyalty:
+			if err := reverseLoyaltyPoints(ctx, store, booking); err != nil {
+				failed = append(failed, fmt.Sprintf("restoring loyalty points: %s", err))
+			}
+		case types.PaymentWallet:
+			entry := &types.WalletEntry{
+				UserID:      booking.UserID,
+				Kind:        types.WalletReversal,
+				Amount:      payment.Amount,
+				Currency:    booking.Price.Currency,
+				BookingID:   booking.ID,
+				Description: fmt.Sprintf("Reversal of payment for booking %s", booking.ID.Hex()),
+				CreatedAt:   time.Now().UTC(),
+			}
+			if _, err := store.Wallet.InsertWalletEntry(ctx, entry); err != nil {
+				failed = append(failed, fmt.Sprintf("reversing wallet payment %s: %s", payment.Reference, err))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("reversing payments of user %s failed: %s", booking.UserID.Hex(), strings.Join(failed, "; "))
+		fmt.Println("Error:", err)
+
+		return err
+	}
+
+	return nil
+}
+
+func restoreGiftCard(ctx context.Context, store *db.Store, code string, amount float64) error {
+	var err error
+	for i := 0; i < giftCardRetries; i++ {
+		var card *types.GiftCard
+		if card, err = store.GiftCard.GetGiftCardByCode(ctx, code); err != nil {
+			return err
+		}
+
+		err = store.GiftCard.UpdateGiftCardBalance(ctx, card, types.RoundMoney(card.Balance+amount))
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// refundBooking credits the money collected for a canceled booking to the
+// user's wallet as store credit and gives pass tickets back. Gateway payments
+// that were never captured are not refunded, there is nothing to give back,
+// and neither are add-ons that have already been collected. Redeemed loyalty
+// points are restored separately.
+func refundBooking(ctx context.Context, store *db.Store, booking *types.Booking) error {
+	var amount float64
+	for _, payment := range booking.Payments {
+		switch payment.Method {
+		case types.PaymentLoyalty:
+		case types.PaymentPass:
+			if err := returnPassTicket(ctx, store, booking, payment); err != nil {
+				return err
+			}
+		default:
+			if payment.IsCaptured() {
+				amount += payment.Amount
+			}
+		}
+	}
+	for _, addOn := range booking.AddOns {
+		if addOn.Collected {
+			amount -= types.RoundMoney(addOn.UnitPrice * float64(addOn.Quantity))
+		}
+	}
+	if types.RoundMoney(amount) <= 0 {
+		return nil
+	}
+
+	entry := &types.WalletEntry{
+		UserID:      booking.UserID,
+		Kind:        types.WalletRefund,
+		Amount:      types.RoundMoney(amount),
+		Currency:    booking.Price.Currency,
+		BookingID:   booking.ID,
+		Description: fmt.Sprintf("Refund for booking %s", booking.ID.Hex()),
+		CreatedAt:   time.Now().UTC(),
+	}
+	_, err := store.Wallet.InsertWalletEntry(ctx, entry)
+
+	return err
+}
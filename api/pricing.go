@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+)
+
+// priceBooking computes the price breakdown of a single ticket for the given
+// hall, including the cinema's surcharges for the hall's features, and the
+// add-ons ordered with it, applying the tax rates of the hall's cinema.
+func priceBooking(ctx context.Context, store *db.Store, hall *types.Hall, addOns []types.AddOn) (*types.PriceBreakdown, error) {
+	cinema, err := store.Cinema.GetCinemaByID(ctx, hall.Cinema.Hex())
+	if err != nil {
+		return nil, ErrResourceNotFound("cinema")
+	}
+
+	ticketRate, err := store.Tax.GetApplicableTaxRate(ctx, cinema, types.ProductTicket)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Ticket"
+	if movie, err := store.Movie.GetMovieByID(ctx, hall.Movie.Hex()); err == nil {
+		description = fmt.Sprintf("Ticket - %s", movie.Title)
+	}
+
+	price := types.NewPriceBreakdown(cinema.Currency)
+	price.Add(types.NewLineItem(types.ProductTicket, description, 1, hall.Price, ticketRate.Rate))
+
+	// surcharges for premium formats are part of the ticket
+	for _, feature := range hall.Features {
+		if surcharge := cinema.Surcharges[feature]; surcharge > 0 {
+			description := fmt.Sprintf("%s surcharge", feature.Name())
+			price.Add(types.NewLineItem(types.ProductTicket, description, 1, surcharge, ticketRate.Rate))
+		}
+	}
+
+	if len(addOns) == 0 {
+		return price, nil
+	}
+
+	concessionRate, err := store.Tax.GetApplicableTaxRate(ctx, cinema, types.ProductConcession)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addOn := range addOns {
+		price.Add(types.NewLineItem(types.ProductConcession, addOn.Name, addOn.Quantity, addOn.UnitPrice, concessionRate.Rate))
+	}
+
+	return price, nil
+}
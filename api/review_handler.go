@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"time"
+)
+
+type ReviewHandler struct {
+	store *db.Store
+}
+
+func NewReviewHandler(store *db.Store) *ReviewHandler {
+	return &ReviewHandler{
+		store: store,
+	}
+}
+
+type ReviewQueryParams struct {
+	db.ListOptions
+	// Hidden lists the moderated reviews instead, for admins only.
+	Hidden bool
+}
+
+func (h *ReviewHandler) HandleGetReviews(c *fiber.Ctx) error {
+	var params ReviewQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	movieID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := checkSort(params.Sort, "createdAt", "stars"); err != nil {
+		return err
+	}
+	if len(params.Sort) == 0 {
+		params.Sort = "-createdAt"
+	}
+
+	if params.Hidden {
+		user, err := getAuthUser(c)
+		if err != nil || !user.IsPlatformAdmin() {
+			return ErrUnauthorized()
+		}
+	}
+
+	filter := db.Map{
+		"movieID": movieID,
+		"hidden":  params.Hidden,
+	}
+	reviews, err := h.store.Review.GetReviews(c.Context(), filter, &params.ListOptions)
+	if err != nil {
+		return err
+	}
+
+	total, err := h.store.Review.CountReviews(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	resp := ResourceResponse{
+		Results: len(reviews),
+		Data:    reviews,
+		Page:    int(params.Page),
+		Total:   total,
+	}
+	return c.JSON(resp)
+}
+
+// HandlePostReview lets users review a movie they have seen, which is a movie
+// they have a past booking for that was not canceled. Every user can review a
+// movie once.
+func (h *ReviewHandler) HandlePostReview(c *fiber.Ctx) error {
+	var params types.CreateReviewParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	id := c.Params("id")
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	movie, err := h.store.Movie.GetMovieByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("movie")
+	}
+
+	booking, err := h.findAttendedBooking(c.Context(), user, movie)
+	if err != nil {
+		return err
+	}
+	if booking == nil {
+		return NewError(http.StatusForbidden, "only customers who have seen the movie can review it")
+	}
+
+	review := types.NewReviewFromParams(params, movie, user, booking)
+	inserted, err := h.store.Review.InsertReview(c.Context(), review)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return NewError(http.StatusConflict, "you have already reviewed this movie")
+	}
+
+	if err := updateMovieRating(c.Context(), h.store, movie.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(review)
+}
+
+// findAttendedBooking returns a past booking of the user for the movie, or
+// nil. Bookings made before they recorded their movie are matched by the
+// halls showing it.
+func (h *ReviewHandler) findAttendedBooking(ctx context.Context, user *types.User, movie *types.Movie) (*types.Booking, error) {
+	halls, err := h.store.Hall.GetHalls(ctx, db.Map{"movie": movie.ID}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hallIDs := make([]primitive.ObjectID, len(halls))
+	for i, hall := range halls {
+		hallIDs[i] = hall.ID
+	}
+
+	filter := db.Map{
+		"userID":   user.ID,
+		"canceled": false,
+		"date":     db.Map{"$lt": time.Now()},
+		"$or": []db.Map{
+			{"movieID": movie.ID},
+			{"movieID": db.Map{"$exists": false}, "hallID": db.Map{"$in": hallIDs}},
+		},
+	}
+	bookings, err := h.store.Booking.GetBookings(ctx, filter, &db.Pagination{Page: 1, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(bookings) == 0 {
+		return nil, nil
+	}
+
+	return bookings[0], nil
+}
+
+func (h *ReviewHandler) HandleHideReview(c *fiber.Ctx) error {
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	update := db.Map{
+		"hidden":   true,
+		"hiddenBy": user.ID,
+		"hiddenAt": time.Now().UTC(),
+	}
+	return h.moderate(c, update)
+}
+
+func (h *ReviewHandler) HandleRestoreReview(c *fiber.Ctx) error {
+	update := db.Map{
+		"hidden":   false,
+		"hiddenBy": primitive.NilObjectID,
+		"hiddenAt": time.Time{},
+	}
+	return h.moderate(c, update)
+}
+
+func (h *ReviewHandler) moderate(c *fiber.Ctx, update db.Map) error {
+	id := c.Params("id")
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	review, err := h.store.Review.GetReviewByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("review")
+		}
+
+		return err
+	}
+
+	if err := h.store.Review.UpdateReview(c.Context(), review.ID, update); err != nil {
+		return err
+	}
+
+	if err := updateMovieRating(c.Context(), h.store, review.MovieID); err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]string{"updated": id})
+}
+
+// updateMovieRating recomputes the rating of a movie from its visible
+// reviews.
+func updateMovieRating(ctx context.Context, store *db.Store, movieID primitive.ObjectID) error {
+	summary, err := store.Review.SummarizeReviews(ctx, db.Map{"movieID": movieID, "hidden": false})
+	if err != nil {
+		return err
+	}
+
+	return store.Movie.UpdateMovie(ctx, movieID.Hex(), db.Map{"rating": summary})
+}
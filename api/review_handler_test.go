@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostReview(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser     = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		viewer        = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		stranger      = fixtures.AddUser(tdb.Store, "james", "foo", false)
+		cinema        = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie         = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall          = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		app           = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1         = app.Group("/", JWTAuthentication(tdb.User))
+		admin         = apiV1.Group("/admin", AdminAuth)
+		reviewHandler = NewReviewHandler(tdb.Store)
+	)
+
+	fixtures.AddBooking(tdb.Store, viewer.ID, hall.ID, types.Evening, time.Now().AddDate(0, 0, -2))
+
+	apiV1.Post("/movie/:id/reviews", reviewHandler.HandlePostReview)
+	admin.Post("/review/:id/hide", reviewHandler.HandleHideReview)
+
+	send := func(user *types.User, target string, body any) *http.Response {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", target, bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	target := "/movie/" + movie.ID.Hex() + "/reviews"
+	params := types.CreateReviewParams{Stars: 4, Text: "Unsettling in the best way."}
+
+	resp := send(stranger, target, params)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status code %d for a user who has not seen the movie, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	resp = send(viewer, target, params)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var review types.Review
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Author != "heron P." {
+		t.Fatalf("expected the author to be heron P., got %s", review.Author)
+	}
+
+	resp = send(viewer, target, params)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d for a second review, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	rated, err := tdb.Movie.GetMovieByID(context.TODO(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rated.Rating.Count != 1 || rated.Rating.Average != 4 {
+		t.Fatalf("expected a rating of 4 from 1 review, got %+v", rated.Rating)
+	}
+
+	resp = send(adminUser, "/admin/review/"+review.ID.Hex()+"/hide", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	rated, err = tdb.Movie.GetMovieByID(context.TODO(), movie.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rated.Rating.Count != 0 {
+		t.Fatalf("expected hidden reviews not to count, got %+v", rated.Rating)
+	}
+}
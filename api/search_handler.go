@@ -0,0 +1,92 @@
+package api
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+type SearchHandler struct {
+	store *db.Store
+}
+
+func NewSearchHandler(store *db.Store) *SearchHandler {
+	return &SearchHandler{
+		store: store,
+	}
+}
+
+type SearchParams struct {
+	Q string
+	// Type restricts the search to "movie" or "cinema".
+	Type  string
+	Limit int64
+}
+
+type SearchResponse struct {
+	Movies  []*types.MovieHit  `json:"movies"`
+	Cinemas []*types.CinemaHit `json:"cinemas"`
+}
+
+// HandleSearch searches movies by title, cast, director and synopsis and
+// open cinemas by name and location. The last word of the query also matches
+// as a prefix.
+func (h *SearchHandler) HandleSearch(c *fiber.Ctx) error {
+	var params SearchParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if len(strings.TrimSpace(params.Q)) == 0 {
+		return NewError(http.StatusBadRequest, "q is required")
+	}
+
+	if params.Type != "" && params.Type != "movie" && params.Type != "cinema" {
+		return NewError(http.StatusBadRequest, "type should be movie or cinema")
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = defaultSearchLimit
+	}
+	if params.Limit > maxSearchLimit {
+		params.Limit = maxSearchLimit
+	}
+
+	resp := SearchResponse{
+		Movies:  []*types.MovieHit{},
+		Cinemas: []*types.CinemaHit{},
+	}
+
+	if params.Type != "cinema" {
+		movies, err := h.store.Movie.SearchMovies(c.Context(), params.Q, db.Map{}, params.Limit)
+		if err != nil {
+			return err
+		}
+		resp.Movies = movies
+
+		for _, hit := range movies {
+			localize(c, hit.Movie)
+		}
+	}
+
+	if params.Type != "movie" {
+		cinemas, err := h.store.Cinema.SearchCinemas(c.Context(), params.Q, db.Map{"retired": db.Map{"$ne": true}}, params.Limit)
+		if err != nil {
+			return err
+		}
+		resp.Cinemas = cinemas
+
+		for _, hit := range cinemas {
+			localize(c, hit.Cinema)
+		}
+	}
+
+	return c.JSON(resp)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user          = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		app           = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1         = app.Group("/", JWTAuthentication(tdb.User))
+		searchHandler = NewSearchHandler(tdb.Store)
+	)
+
+	apiV1.Get("/search", searchHandler.HandleSearch)
+
+	fixtures.AddMovie(tdb.Store, "The Dark Knight", types.Action)
+	fixtures.AddMovie(tdb.Store, "Dark Waters", types.Drama)
+	fixtures.AddMovie(tdb.Store, "Knight and Day", types.Comedy)
+	fixtures.AddCinema(tdb.Store, "Darkroom", "Hamburg", nil)
+	fixtures.AddCinema(tdb.Store, "Babylon", "Berlin", nil)
+
+	search := func(query string) SearchResponse {
+		req := httptest.NewRequest("GET", "/search?q="+url.QueryEscape(query), nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d for %q, got %d", http.StatusOK, query, resp.StatusCode)
+		}
+
+		var found SearchResponse
+		if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+			t.Fatal(err)
+		}
+
+		return found
+	}
+
+	found := search("dark kni")
+	if len(found.Movies) != 1 || found.Movies[0].Movie.Title != "The Dark Knight" {
+		t.Fatalf("expected only The Dark Knight, got %+v", found.Movies)
+	}
+
+	found = search("dark")
+	if len(found.Movies) != 2 {
+		t.Fatalf("expected 2 movies, got %d", len(found.Movies))
+	}
+	if len(found.Cinemas) != 1 || found.Cinemas[0].Cinema.Name != "Darkroom" {
+		t.Fatalf("expected Darkroom to match as a prefix, got %+v", found.Cinemas)
+	}
+
+	found = search("berl")
+	if len(found.Movies) != 0 || len(found.Cinemas) != 1 || found.Cinemas[0].Cinema.Name != "Babylon" {
+		t.Fatalf("expected only Babylon, got %+v", found)
+	}
+}
@@ -0,0 +1,631 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type ShowtimeHandler struct {
+	store *db.Store
+}
+
+func NewShowtimeHandler(store *db.Store) *ShowtimeHandler {
+	return &ShowtimeHandler{
+		store: store,
+	}
+}
+
+type ScheduleResponse struct {
+	Schedule  *types.Schedule   `json:"schedule"`
+	Showtimes []*types.Showtime `json:"showtimes"`
+}
+
+// ShowtimeConflict is a showtime of a new schedule that needs the hall while
+// it is still used by another showtime.
+type ShowtimeConflict struct {
+	Showtime      *types.Showtime `json:"showtime"`
+	ConflictsWith *types.Showtime `json:"conflictsWith"`
+}
+
+type ConflictResponse struct {
+	Message   string             `json:"message"`
+	Conflicts []ShowtimeConflict `json:"conflicts"`
+}
+
+// HandlePostSchedule creates a recurring schedule and its showtimes. It is
+// rejected as a whole when any showtime overlaps another one in the same
+// hall, counting the runtime of the movie plus the cleaning buffer.
+func (h *ShowtimeHandler) HandlePostSchedule(c *fiber.Ctx) error {
+	var params types.CreateScheduleParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	hallID, _ := primitive.ObjectIDFromHex(params.HallID)
+	hall, err := h.store.Hall.GetHallByID(c.Context(), hallID)
+	if err != nil {
+		return ErrResourceNotFound("hall")
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(c.Context(), hall.Cinema.Hex())
+	if err != nil {
+		return ErrResourceNotFound("cinema")
+	}
+	if cinema.Retired {
+		return NewError(http.StatusBadRequest, "showtimes can not be scheduled in a retired cinema")
+	}
+
+	movie, err := h.store.Movie.GetMovieByID(c.Context(), params.MovieID)
+	if err != nil {
+		return ErrResourceNotFound("movie")
+	}
+	if movie.Runtime == 0 {
+		return NewError(http.StatusBadRequest, "the movie needs a runtime to be scheduled")
+	}
+
+	schedule := types.NewScheduleFromParams(params, hall, user)
+	schedule.ID = primitive.NewObjectID()
+
+	showtimes, err := schedule.Showtimes(movie.Runtime)
+	if err != nil {
+		return err
+	}
+	if len(showtimes) == 0 {
+		return NewError(http.StatusBadRequest, "the schedule has no showtimes in its date range")
+	}
+	if showtimes[0].StartsAt.Before(time.Now()) {
+		return NewError(http.StatusBadRequest, "showtimes can not be scheduled in the past")
+	}
+
+	conflicts, err := h.findConflicts(c.Context(), hall.ID, schedule.ID, showtimes)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return c.Status(http.StatusConflict).JSON(ConflictResponse{
+			Message:   "the schedule overlaps other showtimes in the hall",
+			Conflicts: conflicts,
+		})
+	}
+
+	if _, err := h.store.Showtime.InsertSchedule(c.Context(), schedule); err != nil {
+		return err
+	}
+
+	if err := h.store.Showtime.InsertShowtimes(c.Context(), showtimes); err != nil {
+		h.removeSchedule(c.Context(), schedule.ID)
+		return err
+	}
+
+	// a schedule posted for the hall at the same time may have passed the
+	// check as well, both are inserted by now so at least one of them sees
+	// the other and backs out
+	conflicts, err = h.findConflicts(c.Context(), hall.ID, schedule.ID, showtimes)
+	if err != nil {
+		h.removeSchedule(c.Context(), schedule.ID)
+		return err
+	}
+	if len(conflicts) > 0 {
+		h.removeSchedule(c.Context(), schedule.ID)
+		return c.Status(http.StatusConflict).JSON(ConflictResponse{
+			Message:   "the schedule overlaps showtimes scheduled in the hall at the same time",
+			Conflicts: conflicts,
+		})
+	}
+
+	return c.JSON(ScheduleResponse{Schedule: schedule, Showtimes: showtimes})
+}
+
+func (h *ShowtimeHandler) removeSchedule(ctx context.Context, scheduleID primitive.ObjectID) {
+	if _, err := h.store.Showtime.DeleteShowtimes(ctx, db.Map{"scheduleID": scheduleID}); err != nil {
+		fmt.Println("Error deleting showtimes:", err)
+	}
+	if err := h.store.Showtime.DeleteSchedule(ctx, scheduleID); err != nil {
+		fmt.Println("Error deleting schedule:", err)
+	}
+}
+
+// findConflicts checks the showtimes of a schedule, sorted by start, against
+// each other and against the showtimes of other schedules in the hall.
+func (h *ShowtimeHandler) findConflicts(ctx context.Context, hallID, scheduleID primitive.ObjectID, showtimes []*types.Showtime) ([]ShowtimeConflict, error) {
+	conflicts := []ShowtimeConflict{}
+	for i := 1; i < len(showtimes); i++ {
+		if showtimes[i].Overlaps(showtimes[i-1]) {
+			conflicts = append(conflicts, ShowtimeConflict{Showtime: showtimes[i], ConflictsWith: showtimes[i-1]})
+		}
+	}
+
+	filter := db.Map{
+		"hallID":       hallID,
+		"scheduleID":   db.Map{"$ne": scheduleID},
+		"startsAt":     db.Map{"$lt": showtimes[len(showtimes)-1].BlockedUntil},
+		"blockedUntil": db.Map{"$gt": showtimes[0].StartsAt},
+	}
+	scheduled, err := h.store.Showtime.GetShowtimes(ctx, filter, &db.ListOptions{Sort: "startsAt"})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, showtime := range showtimes {
+		for _, other := range scheduled {
+			if other.StartsAt.After(showtime.BlockedUntil) {
+				break
+			}
+			if showtime.Overlaps(other) {
+				conflicts = append(conflicts, ShowtimeConflict{Showtime: showtime, ConflictsWith: other})
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// clearProgramme deletes the schedules matching the filter along with the
+// showtimes that have not started yet. It is used when the hall, cinema or
+// movie they belong to goes away.
+func clearProgramme(ctx context.Context, store *db.Store, filter db.Map) (int, error) {
+	if _, err := store.Showtime.DeleteSchedules(ctx, filter); err != nil {
+		return 0, err
+	}
+
+	upcoming := db.Map{"startsAt": db.Map{"$gte": time.Now()}}
+	for k, v := range filter {
+		upcoming[k] = v
+	}
+
+	return store.Showtime.DeleteShowtimes(ctx, upcoming)
+}
+
+func (h *ShowtimeHandler) HandleGetSchedules(c *fiber.Ctx) error {
+	schedules, err := h.store.Showtime.GetSchedules(c.Context(), db.Map{})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(schedules)
+}
+
+// HandleDeleteSchedule removes a schedule with the showtimes that have not
+// started yet.
+func (h *ShowtimeHandler) HandleDeleteSchedule(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	scheduleID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := h.store.Showtime.DeleteSchedule(c.Context(), scheduleID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("schedule")
+		}
+
+		return err
+	}
+
+	deleted, err := h.store.Showtime.DeleteShowtimes(c.Context(), db.Map{
+		"scheduleID": scheduleID,
+		"startsAt":   db.Map{"$gte": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]any{"deleted": id, "deletedShowtimes": deleted})
+}
+
+type ShowtimeQueryParams struct {
+	db.Pagination
+	Movie  string
+	Cinema string
+	Hall   string
+	// From and To limit the showtimes to the days between them, both
+	// inclusive. From defaults to now.
+	From string
+	To   string
+}
+
+func (p ShowtimeQueryParams) filter() (db.Map, error) {
+	filter := db.Map{}
+
+	for field, id := range map[string]string{"movieID": p.Movie, "cinemaID": p.Cinema, "hallID": p.Hall} {
+		if len(id) == 0 {
+			continue
+		}
+
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, ErrInvalidID()
+		}
+		filter[field] = objID
+	}
+
+	startsAt := db.Map{"$gte": time.Now()}
+	if len(p.From) > 0 {
+		from, err := time.Parse(dateLayout, p.From)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, "from should be a date like 2006-01-02")
+		}
+		startsAt["$gte"] = from
+	}
+	if len(p.To) > 0 {
+		to, err := time.Parse(dateLayout, p.To)
+		if err != nil {
+			return nil, NewError(http.StatusBadRequest, "to should be a date like 2006-01-02")
+		}
+		startsAt["$lt"] = to.AddDate(0, 0, 1)
+	}
+	filter["startsAt"] = startsAt
+
+	return filter, nil
+}
+
+func (h *ShowtimeHandler) HandleGetShowtimes(c *fiber.Ctx) error {
+	var params ShowtimeQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	filter, err := params.filter()
+	if err != nil {
+		return err
+	}
+
+	showtimes, err := h.store.Showtime.GetShowtimes(c.Context(), filter, &db.ListOptions{Pagination: params.Pagination, Sort: "startsAt"})
+	if err != nil {
+		return err
+	}
+
+	resp := ResourceResponse{
+		Results: len(showtimes),
+		Data:    showtimes,
+		Page:    int(params.Page),
+	}
+	return c.JSON(resp)
+}
+
+func (h *ShowtimeHandler) HandlePostRunWindow(c *fiber.Ctx) error {
+	var params types.CreateRunWindowParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	if _, err := h.store.Movie.GetMovieByID(c.Context(), params.MovieID); err != nil {
+		return ErrResourceNotFound("movie")
+	}
+
+	cinema, err := h.store.Cinema.GetCinemaByID(c.Context(), params.CinemaID)
+	if err != nil {
+		return ErrResourceNotFound("cinema")
+	}
+
+	run := types.NewRunWindowFromParams(params)
+	run.OrganizationID = cinema.OrganizationID
+
+	run, err = h.store.Showtime.InsertRunWindow(c.Context(), run)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(run)
+}
+
+func (h *ShowtimeHandler) HandleGetRunWindows(c *fiber.Ctx) error {
+	var params ShowtimeQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	filter := db.Map{}
+	for field, id := range map[string]string{"movieID": params.Movie, "cinemaID": params.Cinema} {
+		if len(id) == 0 {
+			continue
+		}
+
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return ErrInvalidID()
+		}
+		filter[field] = objID
+	}
+
+	runs, err := h.store.Showtime.GetRunWindows(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(runs)
+}
+
+func (h *ShowtimeHandler) HandleDeleteRunWindow(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	runID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidID()
+	}
+
+	if err := h.store.Showtime.DeleteRunWindow(c.Context(), runID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("run window")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"deleted": id})
+}
+
+// showingHorizon is how far ahead a showtime makes a movie count as now
+// showing rather than coming soon.
+const showingHorizon = 7 * 24 * time.Hour
+
+type ListingParams struct {
+	Cinema string
+}
+
+func (p ListingParams) cinemaID() (primitive.ObjectID, error) {
+	if len(p.Cinema) == 0 {
+		return primitive.NilObjectID, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(p.Cinema)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidID()
+	}
+
+	return id, nil
+}
+
+// programme tells which movies are showing now and which are coming soon,
+// with the day they open, in a cinema or, for a zero cinema id, in all open
+// cinemas. It combines run windows, upcoming showtimes, the movies assigned
+// to halls and movie release dates. A hall's movie only counts while the
+// cinema has no run window for it.
+type programme struct {
+	showing map[primitive.ObjectID]bool
+	opens   map[primitive.ObjectID]time.Time
+	movies  map[primitive.ObjectID]*types.Movie
+}
+
+func (p *programme) comingSoon(movieID primitive.ObjectID, opens time.Time) {
+	if current, ok := p.opens[movieID]; !ok || opens.Before(current) {
+		p.opens[movieID] = opens
+	}
+}
+
+type runKey struct {
+	cinemaID primitive.ObjectID
+	movieID  primitive.ObjectID
+}
+
+func (h *ShowtimeHandler) getProgramme(ctx context.Context, cinemaID primitive.ObjectID) (*programme, error) {
+	var (
+		now   = time.Now().UTC()
+		today = now.Truncate(24 * time.Hour)
+		prog  = &programme{
+			showing: map[primitive.ObjectID]bool{},
+			opens:   map[primitive.ObjectID]time.Time{},
+			movies:  map[primitive.ObjectID]*types.Movie{},
+		}
+		scope     = db.Map{}
+		hallScope = db.Map{}
+	)
+
+	if cinemaID.IsZero() {
+		retired, err := h.store.Cinema.GetCinemas(ctx, db.Map{"retired": true}, &db.Pagination{})
+		if err != nil {
+			return nil, err
+		}
+
+		retiredIDs := make([]primitive.ObjectID, len(retired))
+		for i, cinema := range retired {
+			retiredIDs[i] = cinema.ID
+		}
+		scope["cinemaID"] = db.Map{"$nin": retiredIDs}
+		hallScope["cinema"] = db.Map{"$nin": retiredIDs}
+	} else {
+		scope["cinemaID"] = cinemaID
+		hallScope["cinema"] = cinemaID
+	}
+
+	// closed run windows still count, they take a movie off the programme of
+	// the cinema even if a hall has it assigned
+	runs, err := h.store.Showtime.GetRunWindows(ctx, db.Map{"cinemaID": scope["cinemaID"]})
+	if err != nil {
+		return nil, err
+	}
+	hasRun := map[runKey]bool{}
+	for _, run := range runs {
+		hasRun[runKey{cinemaID: run.CinemaID, movieID: run.MovieID}] = true
+		if !run.Closes.IsZero() && run.Closes.Before(today) {
+			continue
+		}
+
+		if run.Opens.After(today) {
+			prog.comingSoon(run.MovieID, run.Opens)
+		} else {
+			prog.showing[run.MovieID] = true
+		}
+	}
+
+	showtimes, err := h.store.Showtime.GetShowtimes(ctx, db.Map{
+		"cinemaID": scope["cinemaID"],
+		"startsAt": db.Map{"$gte": now},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, showtime := range showtimes {
+		if showtime.StartsAt.Before(now.Add(showingHorizon)) {
+			prog.showing[showtime.MovieID] = true
+		} else {
+			prog.comingSoon(showtime.MovieID, showtime.StartsAt.Truncate(24*time.Hour))
+		}
+	}
+
+	halls, err := h.store.Hall.GetHalls(ctx, hallScope, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	movieFilter := db.Map{"_id": db.Map{"$in": programmeMovieIDs(halls, prog)}}
+	if cinemaID.IsZero() {
+		movieFilter = db.Map{"$or": []db.Map{movieFilter, {"releaseDate": db.Map{"$gt": today}}}}
+	}
+	movies, err := h.store.Movie.GetMovies(ctx, movieFilter, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, movie := range movies {
+		prog.movies[movie.ID] = movie
+	}
+
+	// halls show their movie from its release on, unless the cinema plans
+	// the movie with run windows
+	for _, hall := range halls {
+		movie, ok := prog.movies[hall.Movie]
+		if !ok || hasRun[runKey{cinemaID: hall.Cinema, movieID: hall.Movie}] {
+			continue
+		}
+		if movie.ReleaseDate.After(today) {
+			prog.comingSoon(movie.ID, movie.ReleaseDate)
+		} else {
+			prog.showing[movie.ID] = true
+		}
+	}
+
+	if cinemaID.IsZero() {
+		for _, movie := range movies {
+			if movie.ReleaseDate.After(today) {
+				prog.comingSoon(movie.ID, movie.ReleaseDate)
+			}
+		}
+	}
+
+	return prog, nil
+}
+
+// programmeMovieIDs returns the ids of all movies of the programme, including
+// the movies assigned to the halls.
+func programmeMovieIDs(halls []*types.Hall, prog *programme) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{}
+	ids := []primitive.ObjectID{}
+	add := func(id primitive.ObjectID) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, hall := range halls {
+		add(hall.Movie)
+	}
+	for id := range prog.showing {
+		add(id)
+	}
+	for id := range prog.opens {
+		add(id)
+	}
+
+	return ids
+}
+
+// HandleGetNowShowing lists the movies that are on the programme today or
+// have showtimes within the next week, by title.
+func (h *ShowtimeHandler) HandleGetNowShowing(c *fiber.Ctx) error {
+	var params ListingParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	cinemaID, err := params.cinemaID()
+	if err != nil {
+		return err
+	}
+
+	prog, err := h.getProgramme(c.Context(), cinemaID)
+	if err != nil {
+		return err
+	}
+
+	movies := []*types.Movie{}
+	for id := range prog.showing {
+		if movie, ok := prog.movies[id]; ok {
+			movies = append(movies, movie)
+		}
+	}
+	localize(c, movies...)
+	sort.Slice(movies, func(i, j int) bool {
+		return movies[i].Title < movies[j].Title
+	})
+
+	resp := ResourceResponse{
+		Results: len(movies),
+		Data:    movies,
+	}
+	return c.JSON(resp)
+}
+
+// HandleGetComingSoon lists the movies that open later and are not showing
+// yet, the next opening first.
+func (h *ShowtimeHandler) HandleGetComingSoon(c *fiber.Ctx) error {
+	var params ListingParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	cinemaID, err := params.cinemaID()
+	if err != nil {
+		return err
+	}
+
+	prog, err := h.getProgramme(c.Context(), cinemaID)
+	if err != nil {
+		return err
+	}
+
+	movies := []*types.UpcomingMovie{}
+	for id, opens := range prog.opens {
+		movie, ok := prog.movies[id]
+		if !ok || prog.showing[id] {
+			continue
+		}
+		movies = append(movies, &types.UpcomingMovie{Movie: *movie, Opens: opens})
+	}
+	localize(c, movies...)
+	sort.Slice(movies, func(i, j int) bool {
+		if movies[i].Opens.Equal(movies[j].Opens) {
+			return movies[i].Title < movies[j].Title
+		}
+		return movies[i].Opens.Before(movies[j].Opens)
+	})
+
+	resp := ResourceResponse{
+		Results: len(movies),
+		Data:    movies,
+	}
+	return c.JSON(resp)
+}
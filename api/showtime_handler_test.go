@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostScheduleRejectsOverlaps(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser       = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		cinema          = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie           = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall            = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		app             = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin           = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		showtimeHandler = NewShowtimeHandler(tdb.Store)
+		token           = CreateTokenFromUser(adminUser)
+		nextWeek        = time.Now().AddDate(0, 0, 7)
+	)
+
+	if err := tdb.Movie.UpdateMovie(context.TODO(), movie.ID.Hex(), db.Map{"runtime": 110}); err != nil {
+		t.Fatal(err)
+	}
+
+	admin.Post("/schedule", showtimeHandler.HandlePostSchedule)
+
+	send := func(params types.CreateScheduleParams) *http.Response {
+		b, _ := json.Marshal(params)
+		req := httptest.NewRequest("POST", "/schedule", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	params := types.CreateScheduleParams{
+		MovieID:    movie.ID.Hex(),
+		HallID:     hall.ID.Hex(),
+		Days:       []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"},
+		StartTimes: []string{"18:00", "20:15"},
+		From:       nextWeek.Format("2006-01-02"),
+		Until:      nextWeek.AddDate(0, 0, 6).Format("2006-01-02"),
+	}
+
+	// 18:00 + 110 minutes + 15 minutes cleaning ends at 20:05
+	resp := send(params)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var created ScheduleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if len(created.Showtimes) != 14 {
+		t.Fatalf("expected 14 showtimes, got %d", len(created.Showtimes))
+	}
+
+	// a late show at 22:00 would start before the 20:15 show is over
+	params.StartTimes = []string{"22:00"}
+	resp = send(params)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d, got %d", http.StatusConflict, resp.StatusCode)
+	}
+
+	var conflict ConflictResponse
+	if err := json.NewDecoder(resp.Body).Decode(&conflict); err != nil {
+		t.Fatal(err)
+	}
+	if len(conflict.Conflicts) != 7 {
+		t.Fatalf("expected 7 conflicts, got %d", len(conflict.Conflicts))
+	}
+
+	params.StartTimes = []string{"22:30"}
+	resp = send(params)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d for a late show after cleaning, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestNowShowingAndComingSoon(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user            = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		cinema          = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		showing         = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		opening         = fixtures.AddMovie(tdb.Store, "midsommar", types.Horror)
+		released        = fixtures.AddMovie(tdb.Store, "nosferatu", types.Horror)
+		app             = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		apiV1           = app.Group("/", JWTAuthentication(tdb.User))
+		showtimeHandler = NewShowtimeHandler(tdb.Store)
+		inTenDays       = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 10)
+	)
+
+	fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, showing.ID)
+
+	run := types.CreateRunWindowParams{
+		MovieID:  opening.ID.Hex(),
+		CinemaID: cinema.ID.Hex(),
+		Opens:    inTenDays.Format("2006-01-02"),
+	}
+	if _, err := tdb.Showtime.InsertRunWindow(context.TODO(), types.NewRunWindowFromParams(run)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tdb.Movie.UpdateMovie(context.TODO(), released.ID.Hex(), db.Map{"releaseDate": inTenDays.AddDate(0, 1, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	apiV1.Get("/movie/now-showing", showtimeHandler.HandleGetNowShowing)
+	apiV1.Get("/movie/coming-soon", showtimeHandler.HandleGetComingSoon)
+
+	get := func(target string, data any) {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(user))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d for %s, got %d", http.StatusOK, target, resp.StatusCode)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&ResourceResponse{Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var nowShowing []types.Movie
+	get("/movie/now-showing?cinema="+cinema.ID.Hex(), &nowShowing)
+	if len(nowShowing) != 1 || nowShowing[0].ID != showing.ID {
+		t.Fatalf("expected only %s to be showing, got %+v", showing.Title, nowShowing)
+	}
+
+	var comingSoon []types.UpcomingMovie
+	get("/movie/coming-soon", &comingSoon)
+	if len(comingSoon) != 2 || comingSoon[0].ID != opening.ID || comingSoon[1].ID != released.ID {
+		t.Fatalf("expected %s before %s, got %+v", opening.Title, released.Title, comingSoon)
+	}
+	if !comingSoon[0].Opens.Equal(inTenDays) {
+		t.Fatalf("expected %s to open on %s, got %s", opening.Title, inTenDays, comingSoon[0].Opens)
+	}
+
+	comingSoon = nil
+	get("/movie/coming-soon?cinema="+cinema.ID.Hex(), &comingSoon)
+	if len(comingSoon) != 1 || comingSoon[0].ID != opening.ID {
+		t.Fatalf("expected only %s to be coming soon to the cinema, got %+v", opening.Title, comingSoon)
+	}
+
+	// once its run is over the movie of the hall is off the programme
+	closed := types.CreateRunWindowParams{
+		MovieID:  showing.ID.Hex(),
+		CinemaID: cinema.ID.Hex(),
+		Opens:    inTenDays.AddDate(0, 0, -40).Format("2006-01-02"),
+		Closes:   inTenDays.AddDate(0, 0, -11).Format("2006-01-02"),
+	}
+	if _, err := tdb.Showtime.InsertRunWindow(context.TODO(), types.NewRunWindowFromParams(closed)); err != nil {
+		t.Fatal(err)
+	}
+
+	nowShowing = nil
+	get("/movie/now-showing?cinema="+cinema.ID.Hex(), &nowShowing)
+	if len(nowShowing) != 0 {
+		t.Fatalf("expected nothing to be showing after the run closed, got %+v", nowShowing)
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"time"
+)
+
+type SubscriptionHandler struct {
+	store *db.Store
+}
+
+func NewSubscriptionHandler(store *db.Store) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		store: store,
+	}
+}
+
+func (h *SubscriptionHandler) HandlePostPlan(c *fiber.Ctx) error {
+	var params types.CreatePlanParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	plan, err := h.store.Subscription.InsertPlan(c.Context(), types.NewPlanFromParams(params))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(plan)
+}
+
+func (h *SubscriptionHandler) HandleGetPlans(c *fiber.Ctx) error {
+	plans, err := h.store.Subscription.GetPlans(c.Context(), db.Map{"active": true})
+	if err != nil {
+		return ErrResourceNotFound("plan")
+	}
+
+	return c.JSON(plans)
+}
+
+type SubscribeParams struct {
+	PlanID string `json:"planID"`
+}
+
+// HandlePostSubscription starts a pass for the authenticated user. The plan
+// price is left to the payment gateway and the pass can be used once the
+// payment has been captured.
+func (h *SubscriptionHandler) HandlePostSubscription(c *fiber.Ctx) error {
+	var params SubscribeParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	plan, err := h.store.Subscription.GetPlanByID(c.Context(), params.PlanID)
+	if err != nil || !plan.Active {
+		return ErrResourceNotFound("plan")
+	}
+
+	now := time.Now().UTC()
+	active, err := h.store.Subscription.GetSubscriptions(c.Context(), db.Map{
+		"userID":   user.ID,
+		"planID":   plan.ID,
+		"canceled": false,
+		"endsAt":   db.Map{"$gt": now},
+	})
+	if err != nil {
+		return err
+	}
+	if len(active) > 0 {
+		return NewError(http.StatusBadRequest, "you already have an active pass for this plan")
+	}
+
+	sub, err := h.store.Subscription.InsertSubscription(c.Context(), types.NewSubscription(plan, user.ID, now))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sub)
+}
+
+func (h *SubscriptionHandler) HandleGetSubscriptions(c *fiber.Ctx) error {
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	filter := db.Map{
+		"userID":   user.ID,
+		"canceled": false,
+		"endsAt":   db.Map{"$gt": time.Now().UTC()},
+	}
+	subs, err := h.store.Subscription.GetSubscriptions(c.Context(), filter)
+	if err != nil {
+		return ErrResourceNotFound("subscription")
+	}
+
+	return c.JSON(subs)
+}
+
+// HandleCapturePayment records that the payment gateway collected the price of
+// a pass, which activates it.
+func (h *SubscriptionHandler) HandleCapturePayment(c *fiber.Ctx) error {
+	var params types.CapturePaymentParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	id := c.Params("id")
+	if _, err := h.store.Subscription.GetSubscriptionByID(c.Context(), id); err != nil {
+		return ErrResourceNotFound("subscription")
+	}
+
+	if err := h.store.Subscription.CaptureSubscriptionPayment(c.Context(), id, params.Reference); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusConflict, "subscription has no pending payment to capture")
+		}
+
+		return err
+	}
+
+	sub, err := h.store.Subscription.GetSubscriptionByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(sub)
+}
+
+// HandleCancelSubscription ends a pass of the authenticated user. The price
+// collected for it is credited to the wallet if no ticket has been booked with
+// the pass, a used pass is not refunded.
+func (h *SubscriptionHandler) HandleCancelSubscription(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	sub, err := h.store.Subscription.GetSubscriptionByID(c.Context(), id)
+	if err != nil {
+		return ErrResourceNotFound("subscription")
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return ErrUnauthorized()
+	}
+
+	if sub.UserID != user.ID {
+		return ErrUnauthorized()
+	}
+
+	if err := h.store.Subscription.CancelSubscription(c.Context(), id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return NewError(http.StatusBadRequest, "subscription is already canceled")
+		}
+
+		return err
+	}
+
+	// the payment can not be captured anymore, refund what was captured until now
+	if sub, err = h.store.Subscription.GetSubscriptionByID(c.Context(), id); err != nil {
+		return err
+	}
+
+	if err := refundSubscription(c.Context(), h.store, sub); err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]string{"message": "success"})
+}
+
+func refundSubscription(ctx context.Context, store *db.Store, sub *types.Subscription) error {
+	if sub.Payment == nil || !sub.Payment.IsCaptured() {
+		return nil
+	}
+
+	used, err := store.Booking.CountBookings(ctx, db.Map{
+		"canceled": false,
+		"payments": db.Map{"$elemMatch": db.Map{"method": types.PaymentPass, "reference": sub.ID.Hex()}},
+	})
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return nil
+	}
+
+	entry := &types.WalletEntry{
+		UserID:      sub.UserID,
+		Kind:        types.WalletRefund,
+		Amount:      sub.Payment.Amount,
+		Currency:    sub.Currency,
+		Description: fmt.Sprintf("Refund for subscription %s", sub.ID.Hex()),
+		CreatedAt:   time.Now().UTC(),
+	}
+	_, err = store.Wallet.InsertWalletEntry(ctx, entry)
+
+	return err
+}
+
+// chargeToPass takes a ticket off an active, paid pass of the user that
+// covers the booked date, respecting the daily limit of the pass.
+func chargeToPass(ctx context.Context, store *db.Store, user *types.User, booking *types.Booking) (*types.Subscription, error) {
+	filter := db.Map{
+		"userID":         user.ID,
+		"canceled":       false,
+		"payment.status": db.Map{"$ne": types.PaymentPending},
+		"startsAt":       db.Map{"$lte": booking.Date},
+		"endsAt":         db.Map{"$gt": booking.Date},
+	}
+	subs, err := store.Subscription.GetSubscriptions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limitReached := false
+	for _, sub := range subs {
+		if !sub.HasTickets() {
+			continue
+		}
+
+		err := store.Subscription.UseSubscriptionTicket(ctx, sub, booking.Date)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// tell a reached daily limit apart from a used up pass
+			if current, err := store.Subscription.GetSubscriptionByID(ctx, sub.ID.Hex()); err == nil && current.DailyLimit > 0 {
+				limitReached = limitReached || current.DailyUses[types.UsageDay(booking.Date)] >= current.DailyLimit
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return sub, nil
+	}
+
+	if limitReached {
+		return nil, NewError(http.StatusBadRequest, "the daily limit of your pass has been reached")
+	}
+
+	return nil, NewError(http.StatusBadRequest, "no active pass covers this booking")
+}
+
+func returnPassTicket(ctx context.Context, store *db.Store, booking *types.Booking, payment types.Payment) error {
+	subID, err := primitive.ObjectIDFromHex(payment.Reference)
+	if err != nil {
+		return fmt.Errorf("invalid pass reference %q", payment.Reference)
+	}
+
+	return store.Subscription.ReturnSubscriptionTicket(ctx, subID, booking.Date)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionPass(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		user                = fixtures.AddUser(tdb.Store, "heron", "preston", false)
+		adminUser           = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		cinema              = fixtures.AddCinema(tdb.Store, "babylon", "berlin", nil)
+		movie               = fixtures.AddMovie(tdb.Store, "the lighthouse", types.Horror)
+		hall                = fixtures.AddHall(tdb.Store, 100, 10.0, cinema.ID, movie.ID)
+		plan                = fixtures.AddPlan(tdb.Store, "two pack", types.PlanTickets, 30.0, 2, 1)
+		app                 = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		route               = app.Group("/", JWTAuthentication(tdb.User))
+		admin               = app.Group("/admin", JWTAuthentication(tdb.User), AdminAuth)
+		hallHandler         = NewHallHandler(tdb.Store)
+		bookingHandler      = NewBookingHandler(tdb.Store)
+		subscriptionHandler = NewSubscriptionHandler(tdb.Store)
+		date                = time.Now().AddDate(0, 0, 1)
+	)
+
+	route.Post("/hall/:id/book", hallHandler.HandleBookHall)
+	route.Get("/booking/:id/cancel", bookingHandler.HandleCancelBooking)
+	route.Post("/subscription", subscriptionHandler.HandlePostSubscription)
+	route.Get("/subscription/:id/cancel", subscriptionHandler.HandleCancelSubscription)
+	admin.Post("/subscription/:id/capture", subscriptionHandler.HandleCapturePayment)
+
+	do := func(method, target string, body any, token string) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+	bookWithPass := func(session types.Session) *http.Response {
+		params := BookHallParams{Session: session, Date: date, UsePass: true}
+		return do("POST", "/hall/"+hall.ID.Hex()+"/book", params, CreateTokenFromUser(user))
+	}
+
+	resp := do("POST", "/subscription", SubscribeParams{PlanID: plan.ID.Hex()}, CreateTokenFromUser(user))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var sub types.Subscription
+	if err := json.NewDecoder(resp.Body).Decode(&sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Payment == nil || sub.Payment.Status != types.PaymentPending || sub.Payment.Amount != 30.0 {
+		t.Fatalf("expected a pending payment of 30.00, got %+v", sub.Payment)
+	}
+
+	// the pass can not be used before it is paid for
+	if resp := bookWithPass(types.Evening); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected an unpaid pass to be refused, got status code %d", resp.StatusCode)
+	}
+
+	capture := types.CapturePaymentParams{Reference: "ch_3NfXkQ"}
+	if resp := do("POST", "/admin/subscription/"+sub.ID.Hex()+"/capture", capture, CreateTokenFromUser(adminUser)); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	resp = bookWithPass(types.Evening)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var booking types.Booking
+	if err := json.NewDecoder(resp.Body).Decode(&booking); err != nil {
+		t.Fatal(err)
+	}
+	if booking.Payments[0].Method != types.PaymentPass || booking.Payments[0].Reference != sub.ID.Hex() {
+		t.Fatalf("expected the ticket to be paid with the pass, got %+v", booking.Payments)
+	}
+
+	// one ticket per day
+	if resp := bookWithPass(types.Night); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected the daily limit to be enforced, got status code %d", resp.StatusCode)
+	}
+
+	// canceling the booking gives the ticket back
+	if resp := do("GET", "/booking/"+booking.ID.Hex()+"/cancel", nil, CreateTokenFromUser(user)); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	returned, err := tdb.Subscription.GetSubscriptionByID(context.TODO(), sub.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if returned.TicketsRemaining != 2 {
+		t.Fatalf("expected the ticket to be returned, got %d tickets remaining", returned.TicketsRemaining)
+	}
+	if uses := returned.DailyUses[types.UsageDay(date)]; uses != 0 {
+		t.Fatalf("expected the ticket to no longer count against the daily limit, got %d uses", uses)
+	}
+
+	// an unused pass is refunded to the wallet when canceled, but only once
+	for i, expected := range []int{http.StatusOK, http.StatusBadRequest} {
+		if resp := do("GET", "/subscription/"+sub.ID.Hex()+"/cancel", nil, CreateTokenFromUser(user)); resp.StatusCode != expected {
+			t.Fatalf("cancel #%d: expected status code %d, got %d", i+1, expected, resp.StatusCode)
+		}
+	}
+
+	balances, err := tdb.Wallet.GetWalletBalances(context.TODO(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[types.DefaultCurrency] != 30.0 {
+		t.Fatalf("expected a wallet balance of 30.00, got %.2f", balances[types.DefaultCurrency])
+	}
+
+	if resp := bookWithPass(types.Evening); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a canceled pass to be refused, got status code %d", resp.StatusCode)
+	}
+}
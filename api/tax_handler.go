@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"strings"
+)
+
+type TaxHandler struct {
+	store *db.Store
+}
+
+func NewTaxHandler(store *db.Store) *TaxHandler {
+	return &TaxHandler{
+		store: store,
+	}
+}
+
+func (h *TaxHandler) HandlePostTaxRate(c *fiber.Ctx) error {
+	var params types.CreateTaxRateParams
+	if err := c.BodyParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	if errs := params.Validate(); len(errs) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(errs)
+	}
+
+	rate, err := h.store.Tax.InsertTaxRate(c.Context(), types.NewTaxRateFromParams(params))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return NewError(http.StatusConflict, "a tax rate for this cinema or country and product type already exists")
+		}
+
+		return err
+	}
+
+	return c.JSON(rate)
+}
+
+type TaxRateQueryParams struct {
+	Country     string
+	CinemaID    string
+	ProductType types.ProductType
+}
+
+func (h *TaxHandler) HandleGetTaxRates(c *fiber.Ctx) error {
+	var params TaxRateQueryParams
+	if err := c.QueryParser(&params); err != nil {
+		return ErrBadRequest()
+	}
+
+	filter := db.Map{}
+	if len(params.Country) > 0 {
+		filter["country"] = strings.ToUpper(params.Country)
+	}
+	if len(params.CinemaID) > 0 {
+		cinemaID, err := primitive.ObjectIDFromHex(params.CinemaID)
+		if err != nil {
+			return ErrInvalidID()
+		}
+		filter["cinemaID"] = cinemaID
+	}
+	if len(params.ProductType) > 0 {
+		filter["productType"] = params.ProductType
+	}
+
+	rates, err := h.store.Tax.GetTaxRates(c.Context(), filter)
+	if err != nil {
+		return ErrResourceNotFound("tax rate")
+	}
+
+	return c.JSON(rates)
+}
+
+func (h *TaxHandler) HandleDeleteTaxRate(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if !primitive.IsValidObjectID(id) {
+		return ErrInvalidID()
+	}
+
+	if err := h.store.Tax.DeleteTaxRate(c.Context(), id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrResourceNotFound("tax rate")
+		}
+
+		return err
+	}
+
+	return c.JSON(map[string]string{"deleted": id})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db/fixtures"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostTaxRate(t *testing.T) {
+	tdb := setup(t)
+	defer tdb.tearDown(t)
+
+	var (
+		adminUser  = fixtures.AddUser(tdb.Store, "admin", "admin", true)
+		app        = fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		admin      = app.Group("/", JWTAuthentication(tdb.User), AdminAuth)
+		taxHandler = NewTaxHandler(tdb.Store)
+	)
+
+	admin.Post("/tax", taxHandler.HandlePostTaxRate)
+
+	post := func(params types.CreateTaxRateParams) *http.Response {
+		b, _ := json.Marshal(params)
+		req := httptest.NewRequest("POST", "/tax", bytes.NewReader(b))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("X-Api-Token", CreateTokenFromUser(adminUser))
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	params := types.CreateTaxRateParams{Name: "MwSt. 7%", Country: "de", ProductType: types.ProductTicket, Rate: 7}
+	resp := post(params)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var rate types.TaxRate
+	if err := json.NewDecoder(resp.Body).Decode(&rate); err != nil {
+		t.Fatal(err)
+	}
+	if rate.Country != "DE" {
+		t.Fatalf("expected the country to be stored as DE, got %q", rate.Country)
+	}
+
+	// a second rate for the same country and product type would be ambiguous
+	params.Country, params.Rate = "DE", 19
+	if resp := post(params); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d for a duplicate rate, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
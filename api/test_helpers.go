@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,6 +14,7 @@ import (
 
 type testDB struct {
 	client *mongo.Client
+	blobs  *blob.LocalStore
 	*db.Store
 }
 
@@ -33,15 +35,71 @@ func setup(t *testing.T) *testDB {
 		t.Fatal(err)
 	}
 
-	cinemaStore := db.NewMongoCinemaStore(client)
+	var (
+		userStore     = db.NewMongoUserStore(client)
+		cinemaStore   = db.NewMongoCinemaStore(client)
+		movieStore    = db.NewMongoMovieStore(client)
+		hallStore     = db.NewMongoHallStore(client, cinemaStore)
+		orgStore      = db.NewMongoOrganizationStore(client)
+		taxStore      = db.NewMongoTaxStore(client)
+		invoiceStore  = db.NewMongoInvoiceStore(client)
+		reviewStore   = db.NewMongoReviewStore(client)
+		feedbackStore = db.NewMongoFeedbackStore(client)
+	)
+	if err := userStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := cinemaStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := movieStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := hallStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := orgStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := taxStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := invoiceStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := reviewStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := feedbackStore.EnsureIndexes(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := blob.NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	return &testDB{
 		client: client,
+		blobs:  blobs,
 		Store: &db.Store{
-			User:    db.NewMongoUserStore(client),
-			Cinema:  cinemaStore,
-			Movie:   db.NewMongoMovieStore(client),
-			Hall:    db.NewMongoHallStore(client, cinemaStore),
-			Booking: db.NewMongoBookingStore(client),
+			User:         userStore,
+			Cinema:       cinemaStore,
+			Movie:        movieStore,
+			Hall:         hallStore,
+			Booking:      db.NewMongoBookingStore(client),
+			Tax:          taxStore,
+			Invoice:      invoiceStore,
+			GiftCard:     db.NewMongoGiftCardStore(client),
+			Wallet:       db.NewMongoWalletStore(client),
+			Loyalty:      db.NewMongoLoyaltyStore(client),
+			Subscription: db.NewMongoSubscriptionStore(client),
+			Concession:   db.NewMongoConcessionStore(client),
+			Genre:        db.NewMongoGenreStore(client),
+			Showtime:     db.NewMongoShowtimeStore(client),
+			Review:       reviewStore,
+			Feedback:     feedbackStore,
+			Organization: orgStore,
 		},
 	}
 }
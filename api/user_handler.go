@@ -1,11 +1,13 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
 )
 
 type UserHandler struct {
@@ -25,20 +27,46 @@ func (h *UserHandler) HandlePostUser(c *fiber.Ctx) error {
 	}
 
 	if errs := params.Validate(); len(errs) > 0 {
-		return c.JSON(errs)
+		return c.Status(http.StatusBadRequest).JSON(errs)
 	}
 
-	user, err := types.NewUserFromParams(params)
+	insertedUser, err := createUser(c.Context(), h.store.User, params)
 	if err != nil {
 		return err
 	}
 
-	insertedUser, err := h.store.User.InsertUser(c.Context(), user)
+	return c.JSON(insertedUser)
+}
+
+// createUser inserts a new customer account. Email addresses can only be
+// registered once regardless of their casing, a taken one is a conflict.
+func createUser(ctx context.Context, userStore db.UserStore, params types.CreateUserParams) (*types.User, error) {
+	if _, err := userStore.GetUserByEmail(ctx, types.NormalizeEmail(params.Email)); err == nil {
+		return nil, errEmailTaken()
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	user, err := types.NewUserFromParams(params)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return c.JSON(insertedUser)
+	insertedUser, err := userStore.InsertUser(ctx, user)
+	if err != nil {
+		// registered concurrently
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errEmailTaken()
+		}
+
+		return nil, err
+	}
+
+	return insertedUser, nil
+}
+
+func errEmailTaken() *Error {
+	return NewError(http.StatusConflict, "email is already registered")
 }
 
 func (h *UserHandler) HandleGetUser(c *fiber.Ctx) error {
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"fmt"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"strings"
 )
 
 func getAuthUser(c *fiber.Ctx) (*types.User, error) {
@@ -18,4 +21,22 @@ type ResourceResponse struct {
 	Results int `json:"results"`
 	Data    any `json:"data"`
 	Page    int `json:"page"`
+	// Total is the number of resources matching the query on all pages.
+	Total int `json:"total,omitempty"`
+}
+
+// checkSort makes sure a list is only sorted by one of the given fields.
+func checkSort(sort string, fields ...string) error {
+	if len(sort) == 0 {
+		return nil
+	}
+
+	field := strings.TrimPrefix(sort, "-")
+	for _, f := range fields {
+		if f == field {
+			return nil
+		}
+	}
+
+	return NewError(http.StatusBadRequest, fmt.Sprintf("sort should be one of %s, prefixed with - for descending order", strings.Join(fields, ", ")))
 }
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WalletHandler struct {
+	store *db.Store
+}
+
+func NewWalletHandler(store *db.Store) *WalletHandler {
+	return &WalletHandler{
+		store: store,
+	}
+}
+
+type WalletResponse struct {
+	Balances map[string]float64   `json:"balances"`
+	Entries  []*types.WalletEntry `json:"entries"`
+	Page     int                  `json:"page"`
+}
+
+func (h *WalletHandler) HandleGetWallet(c *fiber.Ctx) error {
+	var pag db.Pagination
+	if err := c.QueryParser(&pag); err != nil {
+		return ErrBadRequest()
+	}
+
+	user, err := getAuthUser(c)
+	if err != nil {
+		return err
+	}
+
+	balances, err := h.store.Wallet.GetWalletBalances(c.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.store.Wallet.GetWalletEntries(c.Context(), user.ID, &pag)
+	if err != nil {
+		return err
+	}
+
+	resp := WalletResponse{
+		Balances: balances,
+		Entries:  entries,
+		Page:     int(pag.Page),
+	}
+	return c.JSON(resp)
+}
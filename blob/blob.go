@@ -0,0 +1,66 @@
+// Package blob stores uploaded files such as posters and photos. Files are
+// addressed by slash separated keys and served from a public URL.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+const (
+	BLOB_DRIVER_ENV_NAME    = "BLOB_DRIVER"
+	BLOB_LOCAL_DIR_ENV_NAME = "BLOB_LOCAL_DIR"
+	BLOB_BASE_URL_ENV_NAME  = "BLOB_BASE_URL"
+
+	defaultLocalDir = "media"
+	defaultBaseURL  = "/media"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid blob key")
+
+	// keys are paths of segments that can not start with a dot, so they can
+	// neither escape the storage root nor be hidden files.
+	keyRegex = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*(/[A-Za-z0-9_-][A-Za-z0-9._-]*)*$`)
+)
+
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewStoreFromEnv returns the store configured by BLOB_DRIVER, which is
+// either "local" (the default) or "s3".
+func NewStoreFromEnv() (Store, error) {
+	switch driver := os.Getenv(BLOB_DRIVER_ENV_NAME); driver {
+	case "", "local":
+		return NewLocalStore(getenv(BLOB_LOCAL_DIR_ENV_NAME, defaultLocalDir), getenv(BLOB_BASE_URL_ENV_NAME, defaultBaseURL))
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv(S3_ENDPOINT_ENV_NAME),
+			Region:          os.Getenv(S3_REGION_ENV_NAME),
+			Bucket:          os.Getenv(S3_BUCKET_ENV_NAME),
+			AccessKeyID:     os.Getenv(S3_ACCESS_KEY_ID_ENV_NAME),
+			SecretAccessKey: os.Getenv(S3_SECRET_ACCESS_KEY_ENV_NAME),
+			PublicURL:       os.Getenv(S3_PUBLIC_URL_ENV_NAME),
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q", driver)
+	}
+}
+
+func validKey(key string) bool {
+	return keyRegex.MatchString(key)
+}
+
+func getenv(name, fallback string) string {
+	if value := os.Getenv(name); len(value) > 0 {
+		return value
+	}
+
+	return fallback
+}
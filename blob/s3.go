@@ -0,0 +1,200 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	S3_ENDPOINT_ENV_NAME          = "S3_ENDPOINT"
+	S3_REGION_ENV_NAME            = "S3_REGION"
+	S3_BUCKET_ENV_NAME            = "S3_BUCKET"
+	S3_ACCESS_KEY_ID_ENV_NAME     = "S3_ACCESS_KEY_ID"
+	S3_SECRET_ACCESS_KEY_ENV_NAME = "S3_SECRET_ACCESS_KEY"
+	S3_PUBLIC_URL_ENV_NAME        = "S3_PUBLIC_URL"
+
+	s3Timeout = 30 * time.Second
+)
+
+type S3Config struct {
+	// Endpoint is the URL of the S3 compatible service, e.g.
+	// https://s3.eu-central-1.amazonaws.com or the address of a MinIO server.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PublicURL is where the objects of the bucket can be downloaded from,
+	// e.g. a CDN. It defaults to the bucket on the endpoint.
+	PublicURL string
+}
+
+// S3Store keeps files in a bucket of an S3 compatible object storage. Objects
+// are addressed path-style, which all S3 compatible services support.
+type S3Store struct {
+	config S3Config
+	client *http.Client
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || len(endpoint.Host) == 0 {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+
+	if len(config.Region) == 0 || len(config.Bucket) == 0 {
+		return nil, fmt.Errorf("s3 region and bucket are required")
+	}
+
+	if len(config.AccessKeyID) == 0 || len(config.SecretAccessKey) == 0 {
+		return nil, fmt.Errorf("s3 credentials are required")
+	}
+
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	if len(config.PublicURL) == 0 {
+		config.PublicURL = config.Endpoint + "/" + config.Bucket
+	}
+	config.PublicURL = strings.TrimRight(config.PublicURL, "/")
+
+	return &S3Store{
+		config: config,
+		client: &http.Client{Timeout: s3Timeout},
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	return s.do(req, data)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, nil)
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.config.PublicURL + "/" + key
+}
+
+// objectURL needs no escaping, as valid keys only contain characters that are
+// allowed in URL paths.
+func (s *S3Store) objectURL(key string) string {
+	return s.config.Endpoint + "/" + s.config.Bucket + "/" + key
+}
+
+func (s *S3Store) do(req *http.Request, body []byte) error {
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, msg)
+	}
+
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 to the request, signing the host, the
+// content type and the hash of the body.
+func (s *S3Store) sign(req *http.Request, body []byte, now time.Time) {
+	var (
+		payloadHash = sha256Hex(body)
+		amzDate     = now.Format("20060102T150405Z")
+		date        = now.Format("20060102")
+		scope       = fmt.Sprintf("%s/%s/s3/aws4_request", date, s.config.Region)
+	)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if contentType := req.Header.Get("Content-Type"); len(contentType) > 0 {
+		headers["content-type"] = contentType
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"os"
+	"time"
 )
 
 const bookingColl = "bookings"
@@ -17,6 +18,9 @@ type BookingStore interface {
 	GetBookingByID(context.Context, string) (*types.Booking, error)
 	GetBookings(context.Context, Map, *Pagination) ([]*types.Booking, error)
 	UpdateBooking(context.Context, string, Map) error
+	CancelBooking(context.Context, string) error
+	CapturePayment(context.Context, string, string) error
+	CollectAddOn(context.Context, string, primitive.ObjectID, time.Time) error
 	CountBookings(context.Context, Map) (int, error)
 }
 
@@ -52,7 +56,7 @@ func (s *MongoBookingStore) GetBookingByID(ctx context.Context, id string) (*typ
 	}
 
 	var booking types.Booking
-	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&booking); err != nil {
+	if err := s.coll.FindOne(ctx, scoped(ctx, bson.M{"_id": objID})).Decode(&booking); err != nil {
 		return nil, err
 	}
 
@@ -64,7 +68,7 @@ func (s *MongoBookingStore) GetBookings(ctx context.Context, filter Map, pag *Pa
 	opts.SetSkip((pag.Page - 1) * pag.Limit)
 	opts.SetLimit(pag.Limit)
 
-	cur, err := s.coll.Find(ctx, filter, &opts)
+	cur, err := s.coll.Find(ctx, scoped(ctx, filter), &opts)
 	if err != nil {
 		return nil, err
 	}
@@ -83,15 +87,101 @@ func (s *MongoBookingStore) UpdateBooking(ctx context.Context, id string, update
 		return err
 	}
 
-	if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": update}); err != nil {
+	if _, err := s.coll.UpdateOne(ctx, scoped(ctx, bson.M{"_id": objID}), bson.M{"$set": update}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// CancelBooking marks a booking as canceled. It fails with
+// mongo.ErrNoDocuments if the booking is already canceled, so that follow-up
+// work such as refunds only happens once.
+func (s *MongoBookingStore) CancelBooking(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objID, "canceled": false}
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), bson.M{"$set": bson.M{"canceled": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// CapturePayment records that the gateway collected the pending gateway
+// payment of a booking. It fails with mongo.ErrNoDocuments if the booking is
+// canceled or has no pending gateway payment, so a capture that comes in
+// after the booking was refunded is never recorded as collected.
+func (s *MongoBookingStore) CapturePayment(ctx context.Context, id string, reference string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":      objID,
+		"canceled": false,
+		"payments": bson.M{"$elemMatch": bson.M{
+			"method": types.PaymentGateway,
+			"status": types.PaymentPending,
+		}},
+	}
+	update := bson.M{"$set": bson.M{
+		"payments.$.status":    types.PaymentCaptured,
+		"payments.$.reference": reference,
+	}}
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// CollectAddOn marks an add-on of the booking as handed out. It fails with
+// mongo.ErrNoDocuments if the add-on has already been collected or the
+// booking is canceled, so that an add-on is only handed out once.
+func (s *MongoBookingStore) CollectAddOn(ctx context.Context, id string, concessionID primitive.ObjectID, at time.Time) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":      objID,
+		"canceled": false,
+		"addOns": bson.M{"$elemMatch": bson.M{
+			"concessionID": concessionID,
+			"collected":    false,
+		}},
+	}
+	update := bson.M{"$set": bson.M{
+		"addOns.$.collected":   true,
+		"addOns.$.collectedAt": at,
+	}}
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), update)
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
 func (s *MongoBookingStore) CountBookings(ctx context.Context, filter Map) (int, error) {
-	bookingCount, err := s.coll.CountDocuments(ctx, filter)
+	bookingCount, err := s.coll.CountDocuments(ctx, scoped(ctx, filter))
 	if err != nil {
 		return 0, err
 	}
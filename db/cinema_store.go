@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,6 +18,11 @@ type CinemaStore interface {
 	GetCinemaByID(context.Context, string) (*types.Cinema, error)
 	GetCinemas(context.Context, Map, *Pagination) ([]*types.Cinema, error)
 	UpdateCinema(context.Context, Map, Map) error
+	AddCinemaRating(context.Context, primitive.ObjectID, int) error
+	AddCinemaPhoto(context.Context, primitive.ObjectID, types.Image, int) error
+	RemoveCinemaPhoto(context.Context, primitive.ObjectID, string) error
+	SearchCinemas(context.Context, string, Map, int64) ([]*types.CinemaHit, error)
+	GetCinemasNear(context.Context, types.Coordinates, float64, Map, *Pagination) ([]*types.NearbyCinema, error)
 }
 
 type MongoCinemaStore struct {
@@ -50,7 +56,7 @@ func (s *MongoCinemaStore) GetCinemaByID(ctx context.Context, id string) (*types
 	}
 
 	var cinema *types.Cinema
-	err = s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&cinema)
+	err = s.coll.FindOne(ctx, scoped(ctx, bson.M{"_id": objID})).Decode(&cinema)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +69,7 @@ func (s *MongoCinemaStore) GetCinemas(ctx context.Context, filter Map, pag *Pagi
 	opts.SetSkip((pag.Page - 1) * pag.Limit)
 	opts.SetLimit(pag.Limit)
 
-	cur, err := s.coll.Find(ctx, filter, &opts)
+	cur, err := s.coll.Find(ctx, scoped(ctx, filter), &opts)
 	if err != nil {
 		return nil, err
 	}
@@ -77,10 +83,151 @@ func (s *MongoCinemaStore) GetCinemas(ctx context.Context, filter Map, pag *Pagi
 }
 
 func (s *MongoCinemaStore) UpdateCinema(ctx context.Context, filter Map, update Map) error {
-	_, err := s.coll.UpdateOne(ctx, filter, update)
+	_, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), update)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// AddCinemaRating adds a customer rating to the rating of a cinema. The sum
+// and count are updated in place and the average is derived from them in the
+// same update, so concurrent ratings are never lost.
+func (s *MongoCinemaStore) AddCinemaRating(ctx context.Context, id primitive.ObjectID, stars int) error {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"rating.sum":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating.sum", 0}}, stars}},
+			"rating.count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating.count", 0}}, 1}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"rating.average": bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$rating.sum", "$rating.count"}}, 1}},
+		}}},
+	}
+
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, bson.M{"_id": id}), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// AddCinemaPhoto adds the image to the photos of the cinema unless it already has
+// limit photos, in which case it returns mongo.ErrNoDocuments.
+func (s *MongoCinemaStore) AddCinemaPhoto(ctx context.Context, id primitive.ObjectID, image types.Image, limit int) error {
+	filter := bson.M{"_id": id, fmt.Sprintf("photos.%d", limit-1): bson.M{"$exists": false}}
+
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), bson.M{"$push": bson.M{"photos": image}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *MongoCinemaStore) RemoveCinemaPhoto(ctx context.Context, id primitive.ObjectID, imageID string) error {
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, bson.M{"_id": id}), bson.M{"$pull": bson.M{"photos": bson.M{"id": imageID}}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// MigrateLegacyRatings resets the static ratings cinemas used to be created
+// with. They were not based on customer feedback, so cinemas start out
+// unrated. It only runs once per database.
+func (s *MongoCinemaStore) MigrateLegacyRatings(ctx context.Context) error {
+	return runOnce(ctx, s.coll.Database(), "legacyCinemaRatings", func() error {
+		filter := bson.M{"rating": bson.M{"$type": "number"}}
+		update := bson.M{"$set": bson.M{"rating": types.RatingSummary{}}}
+
+		_, err := s.coll.UpdateMany(ctx, filter, update)
+
+		return err
+	})
+}
+
+// EnsureIndexes creates the text index cinema searches run on, the
+// geospatial index of cinema positions and the indexes of ratings, external
+// IDs and organizations. The text index does not stem words, so that it
+// matches the same words the ranking does.
+func (s *MongoCinemaStore) EnsureIndexes(ctx context.Context) error {
+	keys, weights := textIndexKeys(cinemaSearchFields)
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    keys,
+			Options: options.Index().SetName("cinema_search").SetWeights(weights).SetDefaultLanguage("none"),
+		},
+		{
+			Keys: bson.D{{Key: "geo", Value: "2dsphere"}},
+		},
+		{
+			Keys: bson.D{{Key: "rating.average", Value: -1}},
+		},
+		externalIDIndex,
+		organizationIndex,
+	}
+
+	return createIndexes(ctx, s.coll, indexes...)
+}
+
+// SearchCinemas returns the cinemas matching the filter that contain all words
+// of the query, best match first.
+func (s *MongoCinemaStore) SearchCinemas(ctx context.Context, query string, filter Map, limit int64) ([]*types.CinemaHit, error) {
+	opts := textSearchOptions(query)
+
+	cur, err := s.coll.Find(ctx, scoped(ctx, textSearchFilter(query, cinemaSearchFields, filter)), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var cinemas []*types.Cinema
+	if err := cur.All(ctx, &cinemas); err != nil {
+		return nil, err
+	}
+
+	return rankCinemas(cinemas, query, limit, false), nil
+}
+
+// GetCinemasNear returns the cinemas matching the filter within radius meters
+// of the position, closest first.
+func (s *MongoCinemaStore) GetCinemasNear(ctx context.Context, near types.Coordinates, radius float64, filter Map, pag *Pagination) ([]*types.NearbyCinema, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          types.NewGeoPoint(near),
+			"distanceField": "distance",
+			"maxDistance":   radius,
+			"query":         scoped(ctx, filter),
+			"spherical":     true,
+		}}},
+	}
+	if pag.Page > 1 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: (pag.Page - 1) * pag.Limit}})
+	}
+	if pag.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: pag.Limit}})
+	}
+
+	cur, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	cinemas := []*types.NearbyCinema{}
+	if err := cur.All(ctx, &cinemas); err != nil {
+		return nil, err
+	}
+
+	return cinemas, nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"os"
+)
+
+const concessionColl = "concessions"
+
+type ConcessionStore interface {
+	InsertConcession(context.Context, *types.Concession) (*types.Concession, error)
+	GetConcessionByID(context.Context, string) (*types.Concession, error)
+	GetConcessions(context.Context, Map) ([]*types.Concession, error)
+	UpdateConcession(context.Context, string, Map) error
+	ReserveStock(context.Context, primitive.ObjectID, int) error
+	ReleaseStock(context.Context, primitive.ObjectID, int) error
+}
+
+type MongoConcessionStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoConcessionStore(c *mongo.Client) *MongoConcessionStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoConcessionStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(concessionColl),
+	}
+}
+
+func (s *MongoConcessionStore) InsertConcession(ctx context.Context, concession *types.Concession) (*types.Concession, error) {
+	res, err := s.coll.InsertOne(ctx, concession)
+	if err != nil {
+		return nil, err
+	}
+
+	concession.ID = res.InsertedID.(primitive.ObjectID)
+
+	return concession, nil
+}
+
+func (s *MongoConcessionStore) GetConcessionByID(ctx context.Context, id string) (*types.Concession, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var concession types.Concession
+	if err := s.coll.FindOne(ctx, scoped(ctx, bson.M{"_id": objID})).Decode(&concession); err != nil {
+		return nil, err
+	}
+
+	return &concession, nil
+}
+
+func (s *MongoConcessionStore) GetConcessions(ctx context.Context, filter Map) ([]*types.Concession, error) {
+	cur, err := s.coll.Find(ctx, scoped(ctx, filter))
+	if err != nil {
+		return nil, err
+	}
+
+	var concessions []*types.Concession
+	if err := cur.All(ctx, &concessions); err != nil {
+		return nil, err
+	}
+
+	return concessions, nil
+}
+
+func (s *MongoConcessionStore) UpdateConcession(ctx context.Context, id string, update Map) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, bson.M{"_id": objID}), bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// ReserveStock takes quantity items out of stock. It fails with
+// mongo.ErrNoDocuments if there are not enough items left.
+func (s *MongoConcessionStore) ReserveStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	filter := bson.M{"_id": id, "stock": bson.M{"$gte": quantity}}
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), bson.M{"$inc": bson.M{"stock": -quantity}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *MongoConcessionStore) ReleaseStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
+	_, err := s.coll.UpdateOne(ctx, scoped(ctx, bson.M{"_id": id}), bson.M{"$inc": bson.M{"stock": quantity}})
+
+	return err
+}
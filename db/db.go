@@ -1,5 +1,16 @@
 package db
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
 type Map map[string]any
 
 type Pagination struct {
@@ -7,12 +18,133 @@ type Pagination struct {
 	Page  int64
 }
 
+// ListOptions page and sort the lists that can be sorted.
+type ListOptions struct {
+	Pagination
+	// Sort is the field to sort by, prefixed with "-" for descending order.
+	Sort string
+}
+
+// findOptions returns the find options of the list, a nil list returns
+// everything.
+func (o *ListOptions) findOptions() *options.FindOptions {
+	opts := options.Find()
+	if o == nil {
+		return opts
+	}
+
+	if o.Page > 1 {
+		opts.SetSkip((o.Page - 1) * o.Limit)
+	}
+	opts.SetLimit(o.Limit)
+
+	if len(o.Sort) > 0 {
+		field, order := o.Sort, 1
+		if strings.HasPrefix(field, "-") {
+			field, order = field[1:], -1
+		}
+		opts.SetSort(bson.D{{Key: field, Value: order}, {Key: "_id", Value: 1}})
+	}
+
+	return opts
+}
+
+// IndexConflictError is returned when a unique index can not be created
+// because documents stored before it existed violate it. The store works
+// without the index until the conflicting documents are cleaned up.
+type IndexConflictError struct {
+	Collection string
+	Err        error
+}
+
+func (e *IndexConflictError) Error() string {
+	return fmt.Sprintf("existing %s conflict with a unique index: %s", e.Collection, e.Err)
+}
+
+func (e *IndexConflictError) Unwrap() error {
+	return e.Err
+}
+
+// createIndexes creates the indexes of the collection, telling conflicts
+// with existing documents apart from other failures. When an index conflicts
+// the others are created one by one, so that only the conflicting one is
+// missing.
+func createIndexes(ctx context.Context, coll *mongo.Collection, indexes ...mongo.IndexModel) error {
+	_, err := coll.Indexes().CreateMany(ctx, indexes)
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	if len(indexes) > 1 {
+		for _, index := range indexes {
+			if _, err := coll.Indexes().CreateOne(ctx, index); err != nil && !mongo.IsDuplicateKeyError(err) {
+				return err
+			}
+		}
+	}
+
+	return &IndexConflictError{Collection: coll.Name(), Err: err}
+}
+
+const migrationColl = "migrations"
+
+// runOnce runs a data migration unless it has been recorded as done in the
+// database. Migrations have to be safe to repeat, as two instances starting at
+// the same time may both run one before it is recorded.
+func runOnce(ctx context.Context, database *mongo.Database, name string, migrate func() error) error {
+	migrations := database.Collection(migrationColl)
+
+	err := migrations.FindOne(ctx, bson.M{"_id": name}).Err()
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	if err := migrate(); err != nil {
+		return err
+	}
+
+	_, err = migrations.InsertOne(ctx, bson.M{"_id": name, "ranAt": time.Now().UTC()})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+
+	return err
+}
+
+// externalIDIndex keeps imported documents unique by their ID in the catalog
+// they were imported from. Documents created through the API have none.
+var externalIDIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "externalID", Value: 1}},
+	Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"externalID": bson.M{"$type": "string"}}),
+}
+
+// organizationIndex backs the filter every query of documents owned by an
+// organization is scoped with.
+var organizationIndex = mongo.IndexModel{
+	Keys: bson.D{{Key: "organizationID", Value: 1}},
+}
+
 type Store struct {
-	User    UserStore
-	Cinema  CinemaStore
-	Movie   MovieStore
-	Hall    HallStore
-	Booking BookingStore
+	User         UserStore
+	Cinema       CinemaStore
+	Movie        MovieStore
+	Hall         HallStore
+	Booking      BookingStore
+	Tax          TaxStore
+	Invoice      InvoiceStore
+	GiftCard     GiftCardStore
+	Wallet       WalletStore
+	Loyalty      LoyaltyStore
+	Subscription SubscriptionStore
+	Concession   ConcessionStore
+	Genre        GenreStore
+	Showtime     ShowtimeStore
+	Review       ReviewStore
+	Feedback     FeedbackStore
+	Organization OrganizationStore
 }
 
 const MONGO_DB_ENV_NAME = "MONGO_DB_NAME"
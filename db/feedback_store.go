@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const feedbackColl = "feedback"
+
+type FeedbackStore interface {
+	InsertFeedback(context.Context, *types.Feedback) (bool, error)
+	GetFeedback(context.Context, Map, *ListOptions) ([]*types.Feedback, error)
+	CountFeedback(context.Context, Map) (int, error)
+}
+
+type MongoFeedbackStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoFeedbackStore(c *mongo.Client) *MongoFeedbackStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoFeedbackStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(feedbackColl),
+	}
+}
+
+// EnsureIndexes creates the index allowing a single rating per booking.
+func (s *MongoFeedbackStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll, mongo.IndexModel{
+		Keys:    bson.D{{Key: "bookingID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+// InsertFeedback inserts the feedback unless the booking has already been
+// rated, in which case it returns false.
+func (s *MongoFeedbackStore) InsertFeedback(ctx context.Context, feedback *types.Feedback) (bool, error) {
+	filter := bson.M{"bookingID": feedback.BookingID}
+	update := bson.M{"$setOnInsert": feedback}
+
+	// an upsert racing another one for the same booking fails on the index
+	res, err := s.coll.UpdateOne(ctx, scoped(ctx, filter), update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if res.UpsertedID == nil {
+		return false, nil
+	}
+
+	feedback.ID = res.UpsertedID.(primitive.ObjectID)
+
+	return true, nil
+}
+
+func (s *MongoFeedbackStore) GetFeedback(ctx context.Context, filter Map, opts *ListOptions) ([]*types.Feedback, error) {
+	cur, err := s.coll.Find(ctx, scoped(ctx, filter), opts.findOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	feedback := []*types.Feedback{}
+	if err := cur.All(ctx, &feedback); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+func (s *MongoFeedbackStore) CountFeedback(ctx context.Context, filter Map) (int, error) {
+	count, err := s.coll.CountDocuments(ctx, scoped(ctx, filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
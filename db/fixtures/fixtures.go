@@ -31,7 +31,7 @@ func AddUser(store *db.Store, fName, lName string, admin bool) *types.User {
 	return insertedUser
 }
 
-func AddCinema(store *db.Store, name, location string, rating int, hallIDs []primitive.ObjectID) *types.Cinema {
+func AddCinema(store *db.Store, name, location string, hallIDs []primitive.ObjectID) *types.Cinema {
 	if hallIDs == nil {
 		hallIDs = []primitive.ObjectID{}
 	}
@@ -40,7 +40,6 @@ func AddCinema(store *db.Store, name, location string, rating int, hallIDs []pri
 		Name:     name,
 		Location: location,
 		Halls:    hallIDs,
-		Rating:   rating,
 	}
 
 	insertedCinema, err := store.Cinema.InsertCinema(context.Background(), cinema)
@@ -51,10 +50,10 @@ func AddCinema(store *db.Store, name, location string, rating int, hallIDs []pri
 	return insertedCinema
 }
 
-func AddMovie(store *db.Store, title string, genre types.Genre) *types.Movie {
+func AddMovie(store *db.Store, title string, genres ...types.Genre) *types.Movie {
 	movie := &types.Movie{
-		Title: title,
-		Genre: genre,
+		Title:  title,
+		Genres: genres,
 	}
 
 	insertedMovie, err := store.Movie.InsertMovie(context.Background(), movie)
@@ -96,3 +95,86 @@ func AddBooking(store *db.Store, userID, hallID primitive.ObjectID, session type
 
 	return insertedBooking
 }
+
+func AddTaxRate(store *db.Store, name, country string, cinemaID primitive.ObjectID, productType types.ProductType, rate float64) *types.TaxRate {
+	taxRate := &types.TaxRate{
+		Name:        name,
+		Country:     country,
+		CinemaID:    cinemaID,
+		ProductType: productType,
+		Rate:        rate,
+	}
+
+	insertedTaxRate, err := store.Tax.InsertTaxRate(context.Background(), taxRate)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return insertedTaxRate
+}
+
+func AddGiftCard(store *db.Store, code string, balance float64) *types.GiftCard {
+	card := &types.GiftCard{
+		Code:           code,
+		InitialBalance: balance,
+		Balance:        balance,
+		Currency:       types.DefaultCurrency,
+		IssuedAt:       time.Now().UTC(),
+	}
+
+	insertedCard, err := store.GiftCard.InsertGiftCard(context.Background(), card)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return insertedCard
+}
+
+func AddConcession(store *db.Store, cinemaID primitive.ObjectID, name string, price float64, stock int) *types.Concession {
+	concession := &types.Concession{
+		CinemaID: cinemaID,
+		Name:     name,
+		Price:    price,
+		Stock:    stock,
+		Active:   true,
+	}
+
+	insertedConcession, err := store.Concession.InsertConcession(context.Background(), concession)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return insertedConcession
+}
+
+func AddGenre(store *db.Store, id types.Genre, name string) *types.GenreInfo {
+	genre := &types.GenreInfo{
+		ID:   id,
+		Name: name,
+	}
+
+	insertedGenre, err := store.Genre.InsertGenre(context.Background(), genre)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return insertedGenre
+}
+
+func AddPlan(store *db.Store, name string, kind types.PlanKind, price float64, tickets, dailyLimit int) *types.SubscriptionPlan {
+	plan := types.NewPlanFromParams(types.CreatePlanParams{
+		Name:       name,
+		Kind:       kind,
+		Price:      price,
+		PeriodDays: 30,
+		Tickets:    tickets,
+		DailyLimit: dailyLimit,
+	})
+
+	insertedPlan, err := store.Subscription.InsertPlan(context.Background(), plan)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return insertedPlan
+}
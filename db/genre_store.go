@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const genreColl = "genres"
+
+type GenreStore interface {
+	InsertGenre(context.Context, *types.GenreInfo) (*types.GenreInfo, error)
+	GetGenres(context.Context, Map) ([]*types.GenreInfo, error)
+	UpdateGenre(context.Context, types.Genre, Map) error
+	DeleteGenre(context.Context, types.Genre) error
+}
+
+type MongoGenreStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoGenreStore(c *mongo.Client) *MongoGenreStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoGenreStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(genreColl),
+	}
+}
+
+// SeedDefaultGenres fills the catalog with the default genres, so that
+// movies can be created on a fresh database. Genres that already exist are
+// left as they are. It only runs once per database, so default genres an
+// admin deleted later are not brought back.
+func (s *MongoGenreStore) SeedDefaultGenres(ctx context.Context) error {
+	return runOnce(ctx, s.coll.Database(), "defaultGenres", func() error {
+		for _, genre := range types.DefaultGenres() {
+			opts := options.Update().SetUpsert(true)
+			if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": genre.ID}, bson.M{"$setOnInsert": genre}, opts); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *MongoGenreStore) InsertGenre(ctx context.Context, genre *types.GenreInfo) (*types.GenreInfo, error) {
+	if _, err := s.coll.InsertOne(ctx, genre); err != nil {
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+func (s *MongoGenreStore) GetGenres(ctx context.Context, filter Map) ([]*types.GenreInfo, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+
+	cur, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	genres := []*types.GenreInfo{}
+	if err := cur.All(ctx, &genres); err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+func (s *MongoGenreStore) UpdateGenre(ctx context.Context, id types.Genre, update Map) error {
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *MongoGenreStore) DeleteGenre(ctx context.Context, id types.Genre) error {
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
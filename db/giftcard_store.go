@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const giftCardColl = "giftCards"
+
+type GiftCardStore interface {
+	InsertGiftCard(context.Context, *types.GiftCard) (*types.GiftCard, error)
+	GetGiftCardByCode(context.Context, string) (*types.GiftCard, error)
+	GetGiftCards(context.Context, Map, *Pagination) ([]*types.GiftCard, error)
+	UpdateGiftCardBalance(context.Context, *types.GiftCard, float64) error
+}
+
+type MongoGiftCardStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoGiftCardStore(c *mongo.Client) *MongoGiftCardStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoGiftCardStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(giftCardColl),
+	}
+}
+
+func (s *MongoGiftCardStore) InsertGiftCard(ctx context.Context, card *types.GiftCard) (*types.GiftCard, error) {
+	res, err := s.coll.InsertOne(ctx, card)
+	if err != nil {
+		return nil, err
+	}
+
+	card.ID = res.InsertedID.(primitive.ObjectID)
+
+	return card, nil
+}
+
+func (s *MongoGiftCardStore) GetGiftCardByCode(ctx context.Context, code string) (*types.GiftCard, error) {
+	var card types.GiftCard
+	if err := s.coll.FindOne(ctx, bson.M{"code": code}).Decode(&card); err != nil {
+		return nil, err
+	}
+
+	return &card, nil
+}
+
+func (s *MongoGiftCardStore) GetGiftCards(ctx context.Context, filter Map, pag *Pagination) ([]*types.GiftCard, error) {
+	opts := options.FindOptions{}
+	opts.SetSkip((pag.Page - 1) * pag.Limit)
+	opts.SetLimit(pag.Limit)
+
+	cur, err := s.coll.Find(ctx, filter, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []*types.GiftCard
+	if err := cur.All(ctx, &cards); err != nil {
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+// UpdateGiftCardBalance sets the balance of the card only if it still holds
+// the balance it was read with. A concurrent redemption makes it fail with
+// mongo.ErrNoDocuments, so a card can never be spent twice.
+func (s *MongoGiftCardStore) UpdateGiftCardBalance(ctx context.Context, card *types.GiftCard, balance float64) error {
+	filter := bson.M{"_id": card.ID, "balance": card.Balance}
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"balance": balance}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	card.Balance = balance
+
+	return nil
+}
@@ -13,8 +13,12 @@ const hallColl = "halls"
 
 type HallStore interface {
 	InsertHall(context.Context, *types.Hall) (*types.Hall, error)
-	GetHalls(context.Context, Map) ([]*types.Hall, error)
+	GetHallByID(context.Context, primitive.ObjectID) (*types.Hall, error)
+	GetHalls(context.Context, Map, *ListOptions) ([]*types.Hall, error)
+	CountHalls(context.Context, Map) (int, error)
 	GetHallCapacity(context.Context, primitive.ObjectID) (int, error)
+	UpdateHall(context.Context, primitive.ObjectID, Map) error
+	DeleteHall(context.Context, primitive.ObjectID) error
 }
 
 type MongoHallStore struct {
@@ -33,6 +37,11 @@ func NewMongoHallStore(c *mongo.Client, cinemaStore CinemaStore) *MongoHallStore
 	}
 }
 
+// EnsureIndexes creates the indexes of external IDs and organizations.
+func (s *MongoHallStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll, externalIDIndex, organizationIndex)
+}
+
 func (s *MongoHallStore) InsertHall(ctx context.Context, hall *types.Hall) (*types.Hall, error) {
 	res, err := s.coll.InsertOne(ctx, hall)
 	if err != nil {
@@ -51,8 +60,17 @@ func (s *MongoHallStore) InsertHall(ctx context.Context, hall *types.Hall) (*typ
 	return hall, nil
 }
 
-func (s *MongoHallStore) GetHalls(ctx context.Context, filter Map) ([]*types.Hall, error) {
-	cur, err := s.coll.Find(ctx, filter)
+func (s *MongoHallStore) GetHallByID(ctx context.Context, id primitive.ObjectID) (*types.Hall, error) {
+	var hall types.Hall
+	if err := s.coll.FindOne(ctx, scoped(ctx, bson.M{"_id": id})).Decode(&hall); err != nil {
+		return nil, err
+	}
+
+	return &hall, nil
+}
+
+func (s *MongoHallStore) GetHalls(ctx context.Context, filter Map, opts *ListOptions) ([]*types.Hall, error) {
+	cur, err := s.coll.Find(ctx, scoped(ctx, filter), opts.findOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -65,11 +83,60 @@ func (s *MongoHallStore) GetHalls(ctx context.Context, filter Map) ([]*types.Hal
 	return halls, nil
 }
 
+func (s *MongoHallStore) CountHalls(ctx context.Context, filter Map) (int, error) {
+	count, err := s.coll.CountDocuments(ctx, scoped(ctx, filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
 func (s *MongoHallStore) GetHallCapacity(ctx context.Context, hallID primitive.ObjectID) (int, error) {
 	var hall types.Hall
-	if err := s.coll.FindOne(ctx, bson.M{"_id": hallID}).Decode(&hall); err != nil {
+	if err := s.coll.FindOne(ctx, scoped(ctx, bson.M{"_id": hallID})).Decode(&hall); err != nil {
 		return 0, err
 	}
 
 	return hall.Capacity, nil
 }
+
+// UpdateHall applies the update to the hall. When the hall is moved to
+// another cinema, it is removed from the halls of the old cinema and added
+// to the new one.
+func (s *MongoHallStore) UpdateHall(ctx context.Context, id primitive.ObjectID, update Map) error {
+	var old types.Hall
+	err := s.coll.FindOneAndUpdate(ctx, scoped(ctx, bson.M{"_id": id}), bson.M{"$set": update}).Decode(&old)
+	if err != nil {
+		return err
+	}
+
+	cinemaID, ok := update["cinema"].(primitive.ObjectID)
+	if !ok || cinemaID == old.Cinema {
+		return nil
+	}
+
+	filter := Map{"_id": old.Cinema}
+	pull := Map{"$pull": Map{"halls": id}}
+	if err := s.CinemaStore.UpdateCinema(ctx, filter, pull); err != nil {
+		return err
+	}
+
+	filter = Map{"_id": cinemaID}
+	add := Map{"$addToSet": Map{"halls": id}}
+
+	return s.CinemaStore.UpdateCinema(ctx, filter, add)
+}
+
+func (s *MongoHallStore) DeleteHall(ctx context.Context, id primitive.ObjectID) error {
+	var hall types.Hall
+	if err := s.coll.FindOneAndDelete(ctx, scoped(ctx, bson.M{"_id": id})).Decode(&hall); err != nil {
+		return err
+	}
+
+	// remove the hall from its cinema
+	filter := Map{"_id": hall.Cinema}
+	update := Map{"$pull": Map{"halls": id}}
+
+	return s.CinemaStore.UpdateCinema(ctx, filter, update)
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"strings"
+)
+
+const (
+	invoiceColl = "invoices"
+	// numberRetries bounds how often a number taken by a concurrent invoice
+	// is drawn again.
+	numberRetries = 10
+)
+
+type InvoiceStore interface {
+	InsertInvoice(context.Context, *types.Invoice) (*types.Invoice, error)
+	GetInvoiceByBookingID(context.Context, primitive.ObjectID) (*types.Invoice, error)
+}
+
+type MongoInvoiceStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoInvoiceStore(c *mongo.Client) *MongoInvoiceStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoInvoiceStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(invoiceColl),
+	}
+}
+
+// EnsureIndexes creates the indexes allowing a single invoice per booking
+// and a single invoice per number.
+func (s *MongoInvoiceStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "bookingID", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "number", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"number": bson.M{"$gt": ""}}),
+		},
+	)
+}
+
+// InsertInvoice stores the invoice of a booking and numbers it with the next
+// number of the invoice's year, e.g. INV-2024-000001. A number is only taken
+// by an invoice that is stored, so numbers are sequential without gaps. When
+// the booking has been invoiced concurrently the existing invoice is returned
+// instead of a second one, when the number has been taken concurrently the
+// next one is drawn.
+func (s *MongoInvoiceStore) InsertInvoice(ctx context.Context, invoice *types.Invoice) (*types.Invoice, error) {
+	for i := 0; i < numberRetries; i++ {
+		number, err := s.nextNumber(ctx, invoice.IssuedAt.Year())
+		if err != nil {
+			return nil, err
+		}
+
+		invoice.ID = primitive.NilObjectID
+		invoice.Number = number
+
+		res, err := s.coll.InsertOne(ctx, invoice)
+		if err == nil {
+			invoice.ID = res.InsertedID.(primitive.ObjectID)
+			return invoice, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		existing, err := s.GetInvoiceByBookingID(ctx, invoice.BookingID)
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return existing, err
+		}
+	}
+
+	return nil, fmt.Errorf("no free invoice number after %d attempts", numberRetries)
+}
+
+// GetInvoiceByBookingID returns the invoice of the booking, numbering it
+// first if it was stored before invoices were numbered on insert.
+func (s *MongoInvoiceStore) GetInvoiceByBookingID(ctx context.Context, bookingID primitive.ObjectID) (*types.Invoice, error) {
+	var invoice types.Invoice
+	if err := s.coll.FindOne(ctx, bson.M{"bookingID": bookingID}).Decode(&invoice); err != nil {
+		return nil, err
+	}
+
+	if len(invoice.Number) == 0 {
+		if err := s.assignNumber(ctx, &invoice); err != nil {
+			return nil, err
+		}
+	}
+
+	return &invoice, nil
+}
+
+// assignNumber numbers an unnumbered invoice, unless another request
+// numbered it first.
+func (s *MongoInvoiceStore) assignNumber(ctx context.Context, invoice *types.Invoice) error {
+	for i := 0; i < numberRetries; i++ {
+		number, err := s.nextNumber(ctx, invoice.IssuedAt.Year())
+		if err != nil {
+			return err
+		}
+
+		filter := bson.M{"_id": invoice.ID, "number": ""}
+		res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"number": number}})
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return s.coll.FindOne(ctx, bson.M{"_id": invoice.ID}).Decode(invoice)
+		}
+
+		invoice.Number = number
+
+		return nil
+	}
+
+	return fmt.Errorf("no free invoice number after %d attempts", numberRetries)
+}
+
+// nextNumber returns the number following the highest one of the year.
+// Numbers are zero padded, so they sort in the order they were drawn.
+func (s *MongoInvoiceStore) nextNumber(ctx context.Context, year int) (string, error) {
+	prefix := fmt.Sprintf("INV-%d-", year)
+	filter := bson.M{"number": bson.M{"$regex": "^" + prefix}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}}).SetProjection(bson.M{"number": 1})
+
+	var last types.Invoice
+	err := s.coll.FindOne(ctx, filter, opts).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return "", err
+	}
+
+	var seq int64
+	if len(last.Number) > 0 {
+		if _, err := fmt.Sscanf(strings.TrimPrefix(last.Number, prefix), "%d", &seq); err != nil {
+			return "", fmt.Errorf("invalid invoice number %q: %w", last.Number, err)
+		}
+	}
+
+	return fmt.Sprintf("%s%06d", prefix, seq+1), nil
+}
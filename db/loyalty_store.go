@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const (
+	loyaltyColl        = "loyaltyEntries"
+	loyaltyBalanceColl = "loyaltyBalances"
+	settingsColl       = "settings"
+	loyaltySetting     = "loyaltyProgram"
+)
+
+type LoyaltyStore interface {
+	InsertLoyaltyEntry(context.Context, *types.LoyaltyEntry) (*types.LoyaltyEntry, error)
+	GetLoyaltyEntries(context.Context, Map, *Pagination) ([]*types.LoyaltyEntry, error)
+	SumLoyaltyPoints(context.Context, Map) (int, error)
+	GetLoyaltyBalance(context.Context, primitive.ObjectID) (int, error)
+	GetLoyaltyProgram(context.Context) (*types.LoyaltyProgram, error)
+	UpdateLoyaltyProgram(context.Context, *types.LoyaltyProgram) error
+}
+
+// MongoLoyaltyStore keeps the ledger of points movements along with a
+// balance document per user that redemptions are checked against.
+type MongoLoyaltyStore struct {
+	client   *mongo.Client
+	coll     *mongo.Collection
+	balances *mongo.Collection
+	settings *mongo.Collection
+}
+
+func NewMongoLoyaltyStore(c *mongo.Client) *MongoLoyaltyStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoLoyaltyStore{
+		client:   c,
+		coll:     c.Database(dbname).Collection(loyaltyColl),
+		balances: c.Database(dbname).Collection(loyaltyBalanceColl),
+		settings: c.Database(dbname).Collection(settingsColl),
+	}
+}
+
+type loyaltyBalance struct {
+	UserID primitive.ObjectID `bson:"_id"`
+	Points int                `bson:"points"`
+}
+
+// InsertLoyaltyEntry records the entry and applies it to the user's balance.
+// A redemption that the balance does not cover fails with
+// mongo.ErrNoDocuments and records nothing. Reversals always apply, taking
+// back earned points may leave a negative balance.
+func (s *MongoLoyaltyStore) InsertLoyaltyEntry(ctx context.Context, entry *types.LoyaltyEntry) (*types.LoyaltyEntry, error) {
+	if _, err := s.getBalance(ctx, entry.UserID); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": entry.UserID}
+	if entry.Kind == types.LoyaltyRedeem {
+		filter["points"] = bson.M{"$gte": -entry.Points}
+	}
+
+	upd, err := s.balances.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"points": entry.Points}})
+	if err != nil {
+		return nil, err
+	}
+	if upd.MatchedCount == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	res, err := s.coll.InsertOne(ctx, entry)
+	if err != nil {
+		// keep the balance in line with the ledger
+		s.balances.UpdateOne(ctx, bson.M{"_id": entry.UserID}, bson.M{"$inc": bson.M{"points": -entry.Points}})
+		return nil, err
+	}
+
+	entry.ID = res.InsertedID.(primitive.ObjectID)
+
+	return entry, nil
+}
+
+func (s *MongoLoyaltyStore) GetLoyaltyEntries(ctx context.Context, filter Map, pag *Pagination) ([]*types.LoyaltyEntry, error) {
+	opts := options.FindOptions{}
+	opts.SetSkip((pag.Page - 1) * pag.Limit)
+	opts.SetLimit(pag.Limit)
+	opts.SetSort(bson.M{"createdAt": -1})
+
+	cur, err := s.coll.Find(ctx, filter, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*types.LoyaltyEntry
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// SumLoyaltyPoints adds up the points of all matching entries, e.g. the
+// balance of a user or the net points of a booking.
+func (s *MongoLoyaltyStore) SumLoyaltyPoints(ctx context.Context, filter Map) (int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "points": bson.M{"$sum": "$points"}}}},
+	}
+
+	cur, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	var results []struct {
+		Points int `bson:"points"`
+	}
+	if err := cur.All(ctx, &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	return results[0].Points, nil
+}
+
+func (s *MongoLoyaltyStore) GetLoyaltyBalance(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	balance, err := s.getBalance(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance.Points, nil
+}
+
+// getBalance returns the balance document of the user. Users whose points
+// predate balance documents get one from the sum of their ledger the first
+// time they are used, which is before any new entry is recorded for them.
+func (s *MongoLoyaltyStore) getBalance(ctx context.Context, userID primitive.ObjectID) (*loyaltyBalance, error) {
+	var balance loyaltyBalance
+	err := s.balances.FindOne(ctx, bson.M{"_id": userID}).Decode(&balance)
+	if err == nil {
+		return &balance, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	points, err := s.SumLoyaltyPoints(ctx, Map{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	balance = loyaltyBalance{UserID: userID, Points: points}
+	if _, err := s.balances.InsertOne(ctx, &balance); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		// created concurrently, read it back
+		if err := s.balances.FindOne(ctx, bson.M{"_id": userID}).Decode(&balance); err != nil {
+			return nil, err
+		}
+	}
+
+	return &balance, nil
+}
+
+// GetLoyaltyProgram returns the configured program, or the default one if
+// an admin has not configured it yet.
+func (s *MongoLoyaltyStore) GetLoyaltyProgram(ctx context.Context) (*types.LoyaltyProgram, error) {
+	var program types.LoyaltyProgram
+	err := s.settings.FindOne(ctx, bson.M{"_id": loyaltySetting}).Decode(&program)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return types.DefaultLoyaltyProgram(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &program, nil
+}
+
+func (s *MongoLoyaltyStore) UpdateLoyaltyProgram(ctx context.Context, program *types.LoyaltyProgram) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := s.settings.ReplaceOne(ctx, bson.M{"_id": loyaltySetting}, program, opts)
+
+	return err
+}
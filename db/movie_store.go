@@ -2,10 +2,12 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"os"
 )
 
@@ -14,7 +16,13 @@ const movieColl = "movies"
 type MovieStore interface {
 	InsertMovie(context.Context, *types.Movie) (*types.Movie, error)
 	GetMovieByID(context.Context, string) (*types.Movie, error)
-	GetMovies(context.Context, Map) ([]*types.Movie, error)
+	GetMovies(context.Context, Map, *ListOptions) ([]*types.Movie, error)
+	CountMovies(context.Context, Map) (int, error)
+	UpdateMovie(context.Context, string, Map) error
+	DeleteMovie(context.Context, string) error
+	SearchMovies(context.Context, string, Map, int64) ([]*types.MovieHit, error)
+	AddMovieStill(context.Context, primitive.ObjectID, types.Image, int) error
+	RemoveMovieStill(context.Context, primitive.ObjectID, string) error
 }
 
 type MongoMovieStore struct {
@@ -55,8 +63,8 @@ func (s *MongoMovieStore) GetMovieByID(ctx context.Context, id string) (*types.M
 	return &movie, nil
 }
 
-func (s *MongoMovieStore) GetMovies(ctx context.Context, filter Map) ([]*types.Movie, error) {
-	cur, err := s.coll.Find(ctx, filter)
+func (s *MongoMovieStore) GetMovies(ctx context.Context, filter Map, opts *ListOptions) ([]*types.Movie, error) {
+	cur, err := s.coll.Find(ctx, filter, opts.findOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -68,3 +76,126 @@ func (s *MongoMovieStore) GetMovies(ctx context.Context, filter Map) ([]*types.M
 
 	return movies, nil
 }
+
+func (s *MongoMovieStore) CountMovies(ctx context.Context, filter Map) (int, error) {
+	count, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (s *MongoMovieStore) UpdateMovie(ctx context.Context, id string, update Map) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *MongoMovieStore) DeleteMovie(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// AddMovieStill adds the image to the stills of the movie unless it already has
+// limit stills, in which case it returns mongo.ErrNoDocuments.
+func (s *MongoMovieStore) AddMovieStill(ctx context.Context, id primitive.ObjectID, image types.Image, limit int) error {
+	filter := bson.M{"_id": id, fmt.Sprintf("stills.%d", limit-1): bson.M{"$exists": false}}
+
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"stills": image}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *MongoMovieStore) RemoveMovieStill(ctx context.Context, id primitive.ObjectID, imageID string) error {
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"stills": bson.M{"id": imageID}}})
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// MigrateLegacyGenres rewrites movies stored with a single numeric genre to
+// the list of genre ids movies are stored with now. Migrated movies no longer
+// match, so it is safe to run on every start.
+func (s *MongoMovieStore) MigrateLegacyGenres(ctx context.Context) error {
+	for i, genre := range types.LegacyGenres {
+		filter := bson.M{"genre": i, "genres": bson.M{"$exists": false}}
+		update := bson.M{
+			"$set":   bson.M{"genres": []types.Genre{genre}},
+			"$unset": bson.M{"genre": ""},
+		}
+		if _, err := s.coll.UpdateMany(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnsureIndexes creates the text index movie searches run on and the index
+// of external IDs. The text index does not stem words, so that it matches the
+// same words the ranking does.
+func (s *MongoMovieStore) EnsureIndexes(ctx context.Context) error {
+	keys, weights := textIndexKeys(movieSearchFields)
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    keys,
+			Options: options.Index().SetName("movie_search").SetWeights(weights).SetDefaultLanguage("none"),
+		},
+		externalIDIndex,
+	}
+
+	return createIndexes(ctx, s.coll, indexes...)
+}
+
+// SearchMovies returns the movies matching the filter that contain all words
+// of the query, best match first.
+func (s *MongoMovieStore) SearchMovies(ctx context.Context, query string, filter Map, limit int64) ([]*types.MovieHit, error) {
+	opts := textSearchOptions(query)
+
+	cur, err := s.coll.Find(ctx, textSearchFilter(query, movieSearchFields, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var movies []*types.Movie
+	if err := cur.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+
+	return rankMovies(movies, query, limit, false), nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const organizationColl = "organizations"
+
+type OrganizationStore interface {
+	InsertOrganization(context.Context, *types.Organization) (*types.Organization, error)
+	GetOrganizationByID(context.Context, primitive.ObjectID) (*types.Organization, error)
+	GetOrganizations(context.Context, *ListOptions) ([]*types.Organization, error)
+	CountOrganizations(context.Context) (int, error)
+}
+
+type MongoOrganizationStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoOrganizationStore(c *mongo.Client) *MongoOrganizationStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoOrganizationStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(organizationColl),
+	}
+}
+
+// EnsureIndexes creates the index keeping organization names unique.
+func (s *MongoOrganizationStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+	})
+}
+
+func (s *MongoOrganizationStore) InsertOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	res, err := s.coll.InsertOne(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	org.ID = res.InsertedID.(primitive.ObjectID)
+
+	return org, nil
+}
+
+func (s *MongoOrganizationStore) GetOrganizationByID(ctx context.Context, id primitive.ObjectID) (*types.Organization, error) {
+	var org types.Organization
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&org); err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+func (s *MongoOrganizationStore) GetOrganizations(ctx context.Context, opts *ListOptions) ([]*types.Organization, error) {
+	cur, err := s.coll.Find(ctx, bson.M{}, opts.findOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := []*types.Organization{}
+	if err := cur.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+func (s *MongoOrganizationStore) CountOrganizations(ctx context.Context) (int, error) {
+	count, err := s.coll.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
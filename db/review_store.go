@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const reviewColl = "reviews"
+
+type ReviewStore interface {
+	InsertReview(context.Context, *types.Review) (bool, error)
+	GetReviewByID(context.Context, string) (*types.Review, error)
+	GetReviews(context.Context, Map, *ListOptions) ([]*types.Review, error)
+	CountReviews(context.Context, Map) (int, error)
+	UpdateReview(context.Context, primitive.ObjectID, Map) error
+	SummarizeReviews(context.Context, Map) (types.RatingSummary, error)
+}
+
+type MongoReviewStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoReviewStore(c *mongo.Client) *MongoReviewStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoReviewStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(reviewColl),
+	}
+}
+
+// EnsureIndexes creates the index allowing a single review per user and
+// movie.
+func (s *MongoReviewStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll, mongo.IndexModel{
+		Keys:    bson.D{{Key: "movieID", Value: 1}, {Key: "userID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+// InsertReview inserts the review unless the user has already reviewed the
+// movie, in which case it returns false.
+func (s *MongoReviewStore) InsertReview(ctx context.Context, review *types.Review) (bool, error) {
+	filter := bson.M{"movieID": review.MovieID, "userID": review.UserID}
+	update := bson.M{"$setOnInsert": review}
+
+	// an upsert racing another one for the same review fails on the index
+	res, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if res.UpsertedID == nil {
+		return false, nil
+	}
+
+	review.ID = res.UpsertedID.(primitive.ObjectID)
+
+	return true, nil
+}
+
+func (s *MongoReviewStore) GetReviewByID(ctx context.Context, id string) (*types.Review, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var review types.Review
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&review); err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (s *MongoReviewStore) GetReviews(ctx context.Context, filter Map, opts *ListOptions) ([]*types.Review, error) {
+	cur, err := s.coll.Find(ctx, filter, opts.findOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := []*types.Review{}
+	if err := cur.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (s *MongoReviewStore) CountReviews(ctx context.Context, filter Map) (int, error) {
+	count, err := s.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (s *MongoReviewStore) UpdateReview(ctx context.Context, id primitive.ObjectID, update Map) error {
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// SummarizeReviews returns the average stars and number of the reviews
+// matching the filter.
+func (s *MongoReviewStore) SummarizeReviews(ctx context.Context, filter Map) (types.RatingSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "sum": bson.M{"$sum": "$stars"}, "count": bson.M{"$sum": 1}}}},
+	}
+
+	cur, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return types.RatingSummary{}, err
+	}
+
+	var results []struct {
+		Sum   int `bson:"sum"`
+		Count int `bson:"count"`
+	}
+	if err := cur.All(ctx, &results); err != nil {
+		return types.RatingSummary{}, err
+	}
+	if len(results) == 0 {
+		return types.RatingSummary{}, nil
+	}
+
+	return types.NewRatingSummary(results[0].Sum, results[0].Count), nil
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// searchCandidates caps how many matching documents are ranked per
+	// search.
+	searchCandidates = 200
+	// prefixWeight is the share of a field's weight a prefix match earns.
+	prefixWeight = 0.5
+)
+
+type searchField struct {
+	name   string
+	weight float64
+}
+
+var (
+	movieSearchFields = []searchField{
+		{name: "title", weight: 10},
+		{name: "cast", weight: 4},
+		{name: "director", weight: 4},
+		{name: "synopsis", weight: 1},
+	}
+	cinemaSearchFields = []searchField{
+		{name: "name", weight: 10},
+		{name: "location", weight: 5},
+	}
+)
+
+// textIndexKeys returns the keys and weights of the text index backing the
+// search over the fields.
+func textIndexKeys(fields []searchField) (bson.D, bson.M) {
+	keys := bson.D{}
+	weights := bson.M{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field.name, Value: "text"})
+		weights[field.name] = int(field.weight)
+	}
+
+	return keys, weights
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// splitQuery separates the complete words of the query from the last one,
+// which is still being typed unless the query ends with a space.
+func splitQuery(query string) (words []string, partial string) {
+	words = tokenize(query)
+	if len(words) > 0 && !strings.HasSuffix(query, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	return words, partial
+}
+
+// textSearchOptions returns the find options of a search. When the query has
+// complete words the text index rates the matches, so the best candidates are
+// fetched for ranking. A query of a single partial word can not be rated by
+// the index, the first candidates found are ranked instead.
+func textSearchOptions(query string) *options.FindOptions {
+	opts := options.Find().SetLimit(searchCandidates)
+	if words, _ := splitQuery(query); len(words) > 0 {
+		opts.SetSort(bson.M{"score": bson.M{"$meta": "textScore"}})
+	}
+
+	return opts
+}
+
+// textSearchFilter narrows a search down to the documents that contain every
+// word of the query. Complete words go through the text index, the last word
+// is matched as a prefix unless the query ends with a space, so that "dark
+// kni" finds "The Dark Knight".
+func textSearchFilter(query string, fields []searchField, filter Map) Map {
+	words, partial := splitQuery(query)
+	and := []Map{}
+	if len(filter) > 0 {
+		and = append(and, filter)
+	}
+
+	if len(words) > 0 {
+		// quoted terms are all required, unquoted ones would match any
+		phrases := make([]string, len(words))
+		for i, word := range words {
+			phrases[i] = `"` + word + `"`
+		}
+		and = append(and, Map{"$text": Map{"$search": strings.Join(phrases, " ")}})
+	}
+
+	if len(partial) > 0 {
+		prefix := primitive.Regex{Pattern: `\b` + regexp.QuoteMeta(partial), Options: "i"}
+		or := make([]Map, len(fields))
+		for i, field := range fields {
+			or[i] = Map{field.name: prefix}
+		}
+		and = append(and, Map{"$or": or})
+	}
+
+	return Map{"$and": and}
+}
+
+// scoreText rates how well the texts, one per search field, match the query
+// words. Every word has to match at least one field, exactly or as a prefix,
+// otherwise the score is 0.
+func scoreText(words []string, fields []searchField, texts [][]string) float64 {
+	total := 0.0
+	for _, word := range words {
+		wordScore := 0.0
+		for i, field := range fields {
+			best := 0.0
+			for _, text := range texts[i] {
+				for _, token := range tokenize(text) {
+					switch {
+					case token == word:
+						best = field.weight
+					case strings.HasPrefix(token, word) && best < field.weight*prefixWeight:
+						best = field.weight * prefixWeight
+					}
+				}
+			}
+			wordScore += best
+		}
+		if wordScore == 0 {
+			return 0
+		}
+		total += wordScore
+	}
+
+	return total
+}
+
+// matchesText reports whether the texts contain every word of the query the
+// way textSearchFilter requires it: complete words exactly, the partial word
+// as a prefix.
+func matchesText(query string, texts [][]string) bool {
+	words, partial := splitQuery(query)
+
+	tokens := map[string]bool{}
+	for _, field := range texts {
+		for _, text := range field {
+			for _, token := range tokenize(text) {
+				tokens[token] = true
+			}
+		}
+	}
+
+	for _, word := range words {
+		if !tokens[word] {
+			return false
+		}
+	}
+	if len(partial) == 0 {
+		return true
+	}
+	for token := range tokens {
+		if strings.HasPrefix(token, partial) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func movieTexts(movie *types.Movie) [][]string {
+	return [][]string{{movie.Title}, movie.Cast, {movie.Director}, {movie.Synopsis}}
+}
+
+func cinemaTexts(cinema *types.Cinema) [][]string {
+	return [][]string{{cinema.Name}, {cinema.Location}}
+}
+
+// SearchMovies ranks movies by how well they match the query, best match
+// first, leaving out movies that do not match at all. It is the pure Go
+// counterpart of MongoMovieStore.SearchMovies for stores without a text
+// index, e.g. an in-memory MovieStore.
+func SearchMovies(movies []*types.Movie, query string, limit int64) []*types.MovieHit {
+	return rankMovies(movies, query, limit, true)
+}
+
+// SearchCinemas is the cinema counterpart of SearchMovies.
+func SearchCinemas(cinemas []*types.Cinema, query string, limit int64) []*types.CinemaHit {
+	return rankCinemas(cinemas, query, limit, true)
+}
+
+// rankMovies orders the movies by how well they match the query, best match
+// first, and keeps the first limit of them. Movies that do not match are left
+// out if matchedOnly is set, the text index has already matched them
+// otherwise.
+func rankMovies(movies []*types.Movie, query string, limit int64, matchedOnly bool) []*types.MovieHit {
+	words := tokenize(query)
+	hits := []*types.MovieHit{}
+	for _, movie := range movies {
+		if matchedOnly && !matchesText(query, movieTexts(movie)) {
+			continue
+		}
+
+		score := scoreText(words, movieSearchFields, movieTexts(movie))
+		if score > 0 || !matchedOnly {
+			hits = append(hits, &types.MovieHit{Movie: movie, Score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	if limit > 0 && int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// rankCinemas is the cinema counterpart of rankMovies.
+func rankCinemas(cinemas []*types.Cinema, query string, limit int64, matchedOnly bool) []*types.CinemaHit {
+	words := tokenize(query)
+	hits := []*types.CinemaHit{}
+	for _, cinema := range cinemas {
+		if matchedOnly && !matchesText(query, cinemaTexts(cinema)) {
+			continue
+		}
+
+		score := scoreText(words, cinemaSearchFields, cinemaTexts(cinema))
+		if score > 0 || !matchedOnly {
+			hits = append(hits, &types.CinemaHit{Cinema: cinema, Score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	if limit > 0 && int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
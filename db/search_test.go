@@ -0,0 +1,62 @@
+package db
+
+import (
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"testing"
+)
+
+func TestSearchMovies(t *testing.T) {
+	movies := []*types.Movie{
+		{Title: "Batman Begins", MovieMetadata: types.MovieMetadata{Director: "Christopher Nolan"}},
+		{Title: "The Dark Knight", MovieMetadata: types.MovieMetadata{Director: "Christopher Nolan", Cast: []string{"Heath Ledger"}}},
+		{Title: "Knight and Day", MovieMetadata: types.MovieMetadata{Synopsis: "A dark comedy."}},
+		{Title: "Midsommar"},
+	}
+
+	hits := SearchMovies(movies, "dark kni", 10)
+	if len(hits) != 2 || hits[0].Movie.Title != "The Dark Knight" {
+		t.Fatalf("expected The Dark Knight first of 2 hits, got %+v", hits)
+	}
+	if hits[0].Score <= hits[1].Score {
+		t.Fatalf("expected a title match to rank above a synopsis match, got %.1f and %.1f", hits[0].Score, hits[1].Score)
+	}
+
+	// a query ending with a space has no partial word
+	if hits := SearchMovies(movies, "kni ", 10); len(hits) != 0 {
+		t.Fatalf("expected no exact matches of kni, got %+v", hits)
+	}
+
+	if hits := SearchMovies(movies, "nolan", 1); len(hits) != 1 {
+		t.Fatalf("expected the hits to be limited to 1, got %d", len(hits))
+	}
+
+	if hits := SearchMovies(movies, "ledger", 10); len(hits) != 1 || hits[0].Movie.Title != "The Dark Knight" {
+		t.Fatalf("expected the cast to be searched, got %+v", hits)
+	}
+
+	if hits := SearchMovies(movies, "inception", 10); len(hits) != 0 {
+		t.Fatalf("expected no hits, got %+v", hits)
+	}
+}
+
+func TestSearchCinemas(t *testing.T) {
+	cinemas := []*types.Cinema{
+		{Name: "Babylon", Location: "Berlin"},
+		{Name: "Berlinale Palast", Location: "Berlin"},
+		{Name: "Metropol", Location: "Hamburg"},
+	}
+
+	hits := SearchCinemas(cinemas, "berlin", 10)
+	if len(hits) != 2 || hits[0].Cinema.Name != "Berlinale Palast" {
+		t.Fatalf("expected both Berlin cinemas, the one also matching by name first, got %+v", hits)
+	}
+
+	if hits := SearchCinemas(cinemas, "berlinale pal", 10); len(hits) != 1 || hits[0].Cinema.Name != "Berlinale Palast" {
+		t.Fatalf("expected Berlinale Palast only, got %+v", hits)
+	}
+
+	// complete words have to match exactly
+	if hits := SearchCinemas(cinemas, "ber pal", 10); len(hits) != 0 {
+		t.Fatalf("expected no hits, got %+v", hits)
+	}
+}
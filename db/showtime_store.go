@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"os"
+)
+
+const (
+	scheduleColl  = "schedules"
+	showtimeColl  = "showtimes"
+	runWindowColl = "runWindows"
+)
+
+type ShowtimeStore interface {
+	InsertSchedule(context.Context, *types.Schedule) (*types.Schedule, error)
+	GetScheduleByID(context.Context, string) (*types.Schedule, error)
+	GetSchedules(context.Context, Map) ([]*types.Schedule, error)
+	DeleteSchedule(context.Context, primitive.ObjectID) error
+	DeleteSchedules(context.Context, Map) (int, error)
+	InsertShowtimes(context.Context, []*types.Showtime) error
+	GetShowtimes(context.Context, Map, *ListOptions) ([]*types.Showtime, error)
+	DeleteShowtimes(context.Context, Map) (int, error)
+	CountShowtimes(context.Context, Map) (int, error)
+	InsertRunWindow(context.Context, *types.RunWindow) (*types.RunWindow, error)
+	GetRunWindows(context.Context, Map) ([]*types.RunWindow, error)
+	DeleteRunWindow(context.Context, primitive.ObjectID) error
+}
+
+type MongoShowtimeStore struct {
+	client     *mongo.Client
+	schedules  *mongo.Collection
+	coll       *mongo.Collection
+	runWindows *mongo.Collection
+}
+
+func NewMongoShowtimeStore(c *mongo.Client) *MongoShowtimeStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoShowtimeStore{
+		client:     c,
+		schedules:  c.Database(dbname).Collection(scheduleColl),
+		coll:       c.Database(dbname).Collection(showtimeColl),
+		runWindows: c.Database(dbname).Collection(runWindowColl),
+	}
+}
+
+func (s *MongoShowtimeStore) InsertSchedule(ctx context.Context, schedule *types.Schedule) (*types.Schedule, error) {
+	res, err := s.schedules.InsertOne(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.ID = res.InsertedID.(primitive.ObjectID)
+
+	return schedule, nil
+}
+
+func (s *MongoShowtimeStore) GetScheduleByID(ctx context.Context, id string) (*types.Schedule, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedule types.Schedule
+	if err := s.schedules.FindOne(ctx, scoped(ctx, bson.M{"_id": objID})).Decode(&schedule); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (s *MongoShowtimeStore) GetSchedules(ctx context.Context, filter Map) ([]*types.Schedule, error) {
+	cur, err := s.schedules.Find(ctx, scoped(ctx, filter))
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []*types.Schedule{}
+	if err := cur.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (s *MongoShowtimeStore) DeleteSchedule(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.schedules.DeleteOne(ctx, scoped(ctx, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (s *MongoShowtimeStore) DeleteSchedules(ctx context.Context, filter Map) (int, error) {
+	res, err := s.schedules.DeleteMany(ctx, scoped(ctx, filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+func (s *MongoShowtimeStore) InsertShowtimes(ctx context.Context, showtimes []*types.Showtime) error {
+	if len(showtimes) == 0 {
+		return nil
+	}
+
+	docs := make([]any, len(showtimes))
+	for i, showtime := range showtimes {
+		docs[i] = showtime
+	}
+
+	res, err := s.coll.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range res.InsertedIDs {
+		showtimes[i].ID = id.(primitive.ObjectID)
+	}
+
+	return nil
+}
+
+func (s *MongoShowtimeStore) GetShowtimes(ctx context.Context, filter Map, opts *ListOptions) ([]*types.Showtime, error) {
+	cur, err := s.coll.Find(ctx, scoped(ctx, filter), opts.findOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	showtimes := []*types.Showtime{}
+	if err := cur.All(ctx, &showtimes); err != nil {
+		return nil, err
+	}
+
+	return showtimes, nil
+}
+
+func (s *MongoShowtimeStore) DeleteShowtimes(ctx context.Context, filter Map) (int, error) {
+	res, err := s.coll.DeleteMany(ctx, scoped(ctx, filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+func (s *MongoShowtimeStore) CountShowtimes(ctx context.Context, filter Map) (int, error) {
+	count, err := s.coll.CountDocuments(ctx, scoped(ctx, filter))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (s *MongoShowtimeStore) InsertRunWindow(ctx context.Context, run *types.RunWindow) (*types.RunWindow, error) {
+	res, err := s.runWindows.InsertOne(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
+	run.ID = res.InsertedID.(primitive.ObjectID)
+
+	return run, nil
+}
+
+func (s *MongoShowtimeStore) GetRunWindows(ctx context.Context, filter Map) ([]*types.RunWindow, error) {
+	cur, err := s.runWindows.Find(ctx, scoped(ctx, filter))
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []*types.RunWindow{}
+	if err := cur.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (s *MongoShowtimeStore) DeleteRunWindow(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.runWindows.DeleteOne(ctx, scoped(ctx, bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
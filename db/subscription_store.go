@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"os"
+	"time"
+)
+
+const (
+	planColl         = "subscriptionPlans"
+	subscriptionColl = "subscriptions"
+)
+
+type SubscriptionStore interface {
+	InsertPlan(context.Context, *types.SubscriptionPlan) (*types.SubscriptionPlan, error)
+	GetPlanByID(context.Context, string) (*types.SubscriptionPlan, error)
+	GetPlans(context.Context, Map) ([]*types.SubscriptionPlan, error)
+	InsertSubscription(context.Context, *types.Subscription) (*types.Subscription, error)
+	GetSubscriptionByID(context.Context, string) (*types.Subscription, error)
+	GetSubscriptions(context.Context, Map) ([]*types.Subscription, error)
+	CaptureSubscriptionPayment(context.Context, string, string) error
+	CancelSubscription(context.Context, string) error
+	UseSubscriptionTicket(context.Context, *types.Subscription, time.Time) error
+	ReturnSubscriptionTicket(context.Context, primitive.ObjectID, time.Time) error
+}
+
+type MongoSubscriptionStore struct {
+	client *mongo.Client
+	plans  *mongo.Collection
+	coll   *mongo.Collection
+}
+
+func NewMongoSubscriptionStore(c *mongo.Client) *MongoSubscriptionStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoSubscriptionStore{
+		client: c,
+		plans:  c.Database(dbname).Collection(planColl),
+		coll:   c.Database(dbname).Collection(subscriptionColl),
+	}
+}
+
+func (s *MongoSubscriptionStore) InsertPlan(ctx context.Context, plan *types.SubscriptionPlan) (*types.SubscriptionPlan, error) {
+	res, err := s.plans.InsertOne(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.ID = res.InsertedID.(primitive.ObjectID)
+
+	return plan, nil
+}
+
+func (s *MongoSubscriptionStore) GetPlanByID(ctx context.Context, id string) (*types.SubscriptionPlan, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan types.SubscriptionPlan
+	if err := s.plans.FindOne(ctx, bson.M{"_id": objID}).Decode(&plan); err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+func (s *MongoSubscriptionStore) GetPlans(ctx context.Context, filter Map) ([]*types.SubscriptionPlan, error) {
+	cur, err := s.plans.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []*types.SubscriptionPlan
+	if err := cur.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (s *MongoSubscriptionStore) InsertSubscription(ctx context.Context, sub *types.Subscription) (*types.Subscription, error) {
+	res, err := s.coll.InsertOne(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.ID = res.InsertedID.(primitive.ObjectID)
+
+	return sub, nil
+}
+
+func (s *MongoSubscriptionStore) GetSubscriptionByID(ctx context.Context, id string) (*types.Subscription, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub types.Subscription
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&sub); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (s *MongoSubscriptionStore) GetSubscriptions(ctx context.Context, filter Map) ([]*types.Subscription, error) {
+	cur, err := s.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []*types.Subscription
+	if err := cur.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// CaptureSubscriptionPayment records that the gateway collected the price of
+// a pass, which activates it. It fails with mongo.ErrNoDocuments if the pass
+// is canceled or its payment is not pending.
+func (s *MongoSubscriptionStore) CaptureSubscriptionPayment(ctx context.Context, id string, reference string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objID, "canceled": false, "payment.status": types.PaymentPending}
+	update := bson.M{"$set": bson.M{
+		"payment.status":    types.PaymentCaptured,
+		"payment.reference": reference,
+	}}
+	res, err := s.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// CancelSubscription marks a pass as canceled. It fails with
+// mongo.ErrNoDocuments if the pass is already canceled, so that a refund is
+// only given once.
+func (s *MongoSubscriptionStore) CancelSubscription(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": objID, "canceled": false}
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"canceled": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// UseSubscriptionTicket takes one ticket off a ticket pass for a booking on
+// the given date and counts it against the daily limit of the pass. It fails
+// with mongo.ErrNoDocuments if the pass has been used up, reached its daily
+// limit or been canceled in the meantime.
+func (s *MongoSubscriptionStore) UseSubscriptionTicket(ctx context.Context, sub *types.Subscription, date time.Time) error {
+	if sub.Kind == types.PlanUnlimited && sub.DailyLimit <= 0 {
+		return nil
+	}
+
+	var (
+		field  = "dailyUses." + types.UsageDay(date)
+		filter = bson.M{"_id": sub.ID, "canceled": false}
+		inc    = bson.M{field: 1}
+	)
+	if sub.Kind != types.PlanUnlimited {
+		filter["ticketsRemaining"] = bson.M{"$gt": 0}
+		inc["ticketsRemaining"] = -1
+	}
+	if sub.DailyLimit > 0 {
+		filter["$or"] = bson.A{
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{field: bson.M{"$lt": sub.DailyLimit}},
+		}
+	}
+
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$inc": inc})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	if sub.Kind != types.PlanUnlimited {
+		sub.TicketsRemaining--
+	}
+
+	return nil
+}
+
+// ReturnSubscriptionTicket gives the ticket of a canceled booking on the
+// given date back to the pass.
+func (s *MongoSubscriptionStore) ReturnSubscriptionTicket(ctx context.Context, id primitive.ObjectID, date time.Time) error {
+	field := "dailyUses." + types.UsageDay(date)
+	filter := bson.M{"_id": id, field: bson.M{"$gt": 0}}
+	if _, err := s.coll.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{field: -1}}); err != nil {
+		return err
+	}
+
+	filter = bson.M{"_id": id, "kind": types.PlanTickets}
+	_, err := s.coll.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"ticketsRemaining": 1}})
+
+	return err
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const taxRateColl = "taxRates"
+
+type TaxStore interface {
+	InsertTaxRate(context.Context, *types.TaxRate) (*types.TaxRate, error)
+	GetTaxRates(context.Context, Map) ([]*types.TaxRate, error)
+	DeleteTaxRate(context.Context, string) error
+	GetApplicableTaxRate(context.Context, *types.Cinema, types.ProductType) (*types.TaxRate, error)
+}
+
+type MongoTaxStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoTaxStore(c *mongo.Client) *MongoTaxStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoTaxStore{
+		client: c,
+		coll:   c.Database(dbname).Collection(taxRateColl),
+	}
+}
+
+// EnsureIndexes creates the index allowing a single rate per cinema or
+// country and product type, so that the applicable rate is unambiguous.
+func (s *MongoTaxStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll, mongo.IndexModel{
+		Keys:    bson.D{{Key: "cinemaID", Value: 1}, {Key: "country", Value: 1}, {Key: "productType", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+func (s *MongoTaxStore) InsertTaxRate(ctx context.Context, rate *types.TaxRate) (*types.TaxRate, error) {
+	res, err := s.coll.InsertOne(ctx, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	rate.ID = res.InsertedID.(primitive.ObjectID)
+
+	return rate, nil
+}
+
+func (s *MongoTaxStore) GetTaxRates(ctx context.Context, filter Map) ([]*types.TaxRate, error) {
+	cur, err := s.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []*types.TaxRate
+	if err := cur.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (s *MongoTaxStore) DeleteTaxRate(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// GetApplicableTaxRate returns the rate configured for the cinema itself and
+// falls back to the rate of the cinema's country. Products without any
+// configured rate are untaxed, so a zero rate is returned instead of an error.
+func (s *MongoTaxStore) GetApplicableTaxRate(ctx context.Context, cinema *types.Cinema, productType types.ProductType) (*types.TaxRate, error) {
+	filters := []bson.M{
+		{"cinemaID": cinema.ID, "productType": productType},
+	}
+	if len(cinema.Country) > 0 {
+		filters = append(filters, bson.M{"country": cinema.Country, "cinemaID": bson.M{"$exists": false}, "productType": productType})
+	}
+
+	for _, filter := range filters {
+		var rate types.TaxRate
+		err := s.coll.FindOne(ctx, filter).Decode(&rate)
+		if err == nil {
+			return &rate, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	return &types.TaxRate{ProductType: productType}, nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type contextKey string
+
+// TenantKey is the context key of the organization a request is scoped to.
+// Requests of a fiber handler carry it as a user value of the request.
+const TenantKey contextKey = "tenant"
+
+// WithTenant returns a context that scopes the stores to the organization.
+func WithTenant(ctx context.Context, orgID primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, TenantKey, orgID)
+}
+
+// TenantFromContext returns the organization the context is scoped to, if
+// any. Contexts without one, such as those of customers and platform admins,
+// see the data of all organizations.
+func TenantFromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	orgID, ok := ctx.Value(TenantKey).(primitive.ObjectID)
+
+	return orgID, ok && !orgID.IsZero()
+}
+
+// scoped returns a copy of the filter restricted to the documents of the
+// organization the context is scoped to. It is applied to every query of the
+// stores of documents owned by an organization.
+func scoped(ctx context.Context, filter map[string]any) bson.M {
+	scopedFilter := bson.M{}
+	for k, v := range filter {
+		scopedFilter[k] = v
+	}
+
+	if orgID, ok := TenantFromContext(ctx); ok {
+		scopedFilter["organizationID"] = orgID
+	}
+
+	return scopedFilter
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"os"
+	"strings"
 )
 
 const userColl = "users"
@@ -24,6 +25,7 @@ type UserStore interface {
 	GetUsers(context.Context, *Pagination) ([]*types.User, error)
 	UpdateUser(ctx context.Context, filter Map, params types.UpdateUserParams) error
 	DeleteUser(context.Context, string) error
+	SetOrganizationAdmin(context.Context, primitive.ObjectID, primitive.ObjectID) error
 
 	Dropper
 }
@@ -41,6 +43,67 @@ func NewMongoUserStore(c *mongo.Client) *MongoUserStore {
 	}
 }
 
+// EnsureIndexes creates the index keeping the email addresses users log in
+// with unique.
+func (s *MongoUserStore) EnsureIndexes(ctx context.Context) error {
+	return createIndexes(ctx, s.coll, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+}
+
+// MigrateEmails normalizes the email addresses users registered with before
+// addresses were compared case insensitively. Users whose address only
+// differs in casing from another one are left as they are and reported, the
+// migration runs again until they are cleaned up.
+func (s *MongoUserStore) MigrateEmails(ctx context.Context) error {
+	return runOnce(ctx, s.coll.Database(), "normalizedEmails", func() error {
+		filter := bson.M{"$expr": bson.M{"$ne": bson.A{"$email", bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}}}}}
+
+		cur, err := s.coll.Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		var users []*types.User
+		if err := cur.All(ctx, &users); err != nil {
+			return err
+		}
+
+		var conflicts []string
+		for _, user := range users {
+			email := types.NormalizeEmail(user.Email)
+
+			taken, err := s.coll.CountDocuments(ctx, bson.M{"email": email, "_id": bson.M{"$ne": user.ID}})
+			if err != nil {
+				return err
+			}
+			if taken > 0 {
+				conflicts = append(conflicts, user.Email)
+				continue
+			}
+
+			_, err = s.coll.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"email": email}})
+			if mongo.IsDuplicateKeyError(err) {
+				conflicts = append(conflicts, user.Email)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(conflicts) > 0 {
+			return &IndexConflictError{
+				Collection: userColl,
+				Err:        fmt.Errorf("email addresses registered in another casing: %s", strings.Join(conflicts, ", ")),
+			}
+		}
+
+		return nil
+	})
+}
+
 func (s *MongoUserStore) Drop(ctx context.Context) error {
 	fmt.Println("--- dropping users collection ---")
 	return s.coll.Drop(ctx)
@@ -131,3 +194,19 @@ func (s *MongoUserStore) UpdateUser(ctx context.Context, filter Map, params type
 
 	return nil
 }
+
+// SetOrganizationAdmin makes the user an admin of the organization, which
+// limits what the user administers to the organization's cinemas.
+func (s *MongoUserStore) SetOrganizationAdmin(ctx context.Context, id, orgID primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"isAdmin": true, "organizationID": orgID}}
+
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+)
+
+const (
+	walletColl        = "walletEntries"
+	walletBalanceColl = "walletBalances"
+)
+
+// halfCent absorbs the floating point error that builds up in balances
+// updated with $inc, so that a debit of the rounded balance still matches.
+const halfCent = 0.005
+
+type WalletStore interface {
+	InsertWalletEntry(context.Context, *types.WalletEntry) (*types.WalletEntry, error)
+	GetWalletEntries(context.Context, primitive.ObjectID, *Pagination) ([]*types.WalletEntry, error)
+	GetWalletBalances(context.Context, primitive.ObjectID) (map[string]float64, error)
+}
+
+// MongoWalletStore keeps the ledger of wallet entries along with a balance
+// document per user. The balance document is what debits are checked
+// against, so that concurrent payments can not overdraw a wallet.
+type MongoWalletStore struct {
+	client   *mongo.Client
+	coll     *mongo.Collection
+	balances *mongo.Collection
+}
+
+func NewMongoWalletStore(c *mongo.Client) *MongoWalletStore {
+	dbname := os.Getenv(MONGO_DB_ENV_NAME)
+	return &MongoWalletStore{
+		client:   c,
+		coll:     c.Database(dbname).Collection(walletColl),
+		balances: c.Database(dbname).Collection(walletBalanceColl),
+	}
+}
+
+type walletBalance struct {
+	UserID   primitive.ObjectID `bson:"_id"`
+	Balances map[string]float64 `bson:"balances"`
+}
+
+// InsertWalletEntry records the entry and applies it to the balance of the
+// wallet. A debit that the balance does not cover fails with
+// mongo.ErrNoDocuments and records nothing.
+func (s *MongoWalletStore) InsertWalletEntry(ctx context.Context, entry *types.WalletEntry) (*types.WalletEntry, error) {
+	if _, err := s.getBalance(ctx, entry.UserID); err != nil {
+		return nil, err
+	}
+
+	field := "balances." + entry.Currency
+	filter := bson.M{"_id": entry.UserID}
+	if entry.Amount < 0 {
+		filter[field] = bson.M{"$gte": -entry.Amount - halfCent}
+	}
+
+	res, err := s.balances.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{field: entry.Amount}})
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	inserted, err := s.coll.InsertOne(ctx, entry)
+	if err != nil {
+		// keep the balance in line with the ledger
+		s.balances.UpdateOne(ctx, bson.M{"_id": entry.UserID}, bson.M{"$inc": bson.M{field: -entry.Amount}})
+		return nil, err
+	}
+
+	entry.ID = inserted.InsertedID.(primitive.ObjectID)
+
+	return entry, nil
+}
+
+func (s *MongoWalletStore) GetWalletEntries(ctx context.Context, userID primitive.ObjectID, pag *Pagination) ([]*types.WalletEntry, error) {
+	opts := options.FindOptions{}
+	opts.SetSkip((pag.Page - 1) * pag.Limit)
+	opts.SetLimit(pag.Limit)
+	opts.SetSort(bson.M{"createdAt": -1})
+
+	cur, err := s.coll.Find(ctx, bson.M{"userID": userID}, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*types.WalletEntry
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetWalletBalances returns the balance of a user's wallet per currency.
+func (s *MongoWalletStore) GetWalletBalances(ctx context.Context, userID primitive.ObjectID) (map[string]float64, error) {
+	balance, err := s.getBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := map[string]float64{}
+	for currency, amount := range balance.Balances {
+		balances[currency] = types.RoundMoney(amount)
+	}
+
+	return balances, nil
+}
+
+// getBalance returns the balance document of the user. Wallets that predate
+// balance documents get one from the sum of their ledger the first time they
+// are used, which is before any new entry is recorded for them.
+func (s *MongoWalletStore) getBalance(ctx context.Context, userID primitive.ObjectID) (*walletBalance, error) {
+	var balance walletBalance
+	err := s.balances.FindOne(ctx, bson.M{"_id": userID}).Decode(&balance)
+	if err == nil {
+		return &balance, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	sums, err := s.sumLedger(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance = walletBalance{UserID: userID, Balances: sums}
+	if _, err := s.balances.InsertOne(ctx, &balance); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		// created concurrently, read it back
+		if err := s.balances.FindOne(ctx, bson.M{"_id": userID}).Decode(&balance); err != nil {
+			return nil, err
+		}
+	}
+
+	return &balance, nil
+}
+
+// sumLedger sums up the ledger of a user per currency.
+func (s *MongoWalletStore) sumLedger(ctx context.Context, userID primitive.ObjectID) (map[string]float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userID": userID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$currency", "balance": bson.M{"$sum": "$amount"}}}},
+	}
+
+	cur, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Currency string  `bson:"_id"`
+		Balance  float64 `bson:"balance"`
+	}
+	if err := cur.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	sums := map[string]float64{}
+	for _, res := range results {
+		sums[res.Currency] = res.Balance
+	}
+
+	return sums, nil
+}
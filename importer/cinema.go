@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"context"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// CinemaRow is a cinema with its address, position and contact details
+// flattened into columns.
+type CinemaRow struct {
+	ExternalID string   `json:"externalID"`
+	Name       string   `json:"name"`
+	Location   string   `json:"location"`
+	Street     string   `json:"street"`
+	PostalCode string   `json:"postalCode"`
+	City       string   `json:"city"`
+	Lat        *float64 `json:"lat"`
+	Lng        *float64 `json:"lng"`
+	Country    string   `json:"country"`
+	Currency   string   `json:"currency"`
+	Amenities  []string `json:"amenities"`
+	Phone      string   `json:"phone"`
+	Email      string   `json:"email"`
+	Website    string   `json:"website"`
+}
+
+func (r CinemaRow) params() (types.CreateCinemaParams, map[string]string) {
+	params := types.CreateCinemaParams{
+		Name:     strings.TrimSpace(r.Name),
+		Location: r.Location,
+		Country:  r.Country,
+		Currency: r.Currency,
+	}
+
+	if len(r.Street) > 0 || len(r.PostalCode) > 0 || len(r.City) > 0 {
+		params.Address = &types.Address{
+			Street:     r.Street,
+			PostalCode: r.PostalCode,
+			City:       r.City,
+		}
+	}
+
+	errs := map[string]string{}
+	if (r.Lat == nil) != (r.Lng == nil) {
+		errs["coordinates"] = "lat and lng should be given together"
+	} else if r.Lat != nil {
+		params.Coordinates = &types.Coordinates{Lat: *r.Lat, Lng: *r.Lng}
+	}
+
+	for _, amenity := range r.Amenities {
+		params.Amenities = append(params.Amenities, types.Amenity(strings.ToLower(amenity)))
+	}
+
+	if len(r.Phone) > 0 || len(r.Email) > 0 || len(r.Website) > 0 {
+		params.Contact = &types.Contact{
+			Phone:   r.Phone,
+			Email:   r.Email,
+			Website: r.Website,
+		}
+	}
+
+	return params, mergeErrors(errs, params.Validate())
+}
+
+func (i *Importer) importCinemas(ctx context.Context, rows []parsedRow[CinemaRow], dryRun bool, report *Report) error {
+	seen := map[string]int{}
+	for _, row := range rows {
+		externalID := strings.TrimSpace(row.value.ExternalID)
+
+		params, errs := row.value.params()
+		errs = mergeErrors(row.errs, errs)
+
+		if !checkRow(report, row.line, externalID, errs, seen) {
+			continue
+		}
+
+		existing, err := i.findCinema(ctx, externalID)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			if !dryRun {
+				cinema := types.NewCinemaFromParams(params)
+				cinema.ExternalID = externalID
+				if _, err := i.store.Cinema.InsertCinema(ctx, cinema); err != nil {
+					if mongo.IsDuplicateKeyError(err) {
+						report.fail(row.line, externalID, map[string]string{"externalID": "cinema was imported concurrently"})
+						continue
+					}
+
+					return err
+				}
+			}
+			report.Created++
+			continue
+		}
+
+		if !dryRun {
+			update := types.UpdateCinemaParams{
+				Name:         params.Name,
+				Location:     params.Location,
+				Address:      params.Address,
+				Coordinates:  params.Coordinates,
+				OpeningHours: params.OpeningHours,
+				Amenities:    params.Amenities,
+				Contact:      params.Contact,
+				Country:      params.Country,
+				Currency:     params.Currency,
+			}.ToBSON()
+			if err := i.store.Cinema.UpdateCinema(ctx, db.Map{"_id": existing.ID}, db.Map{"$set": update}); err != nil {
+				return err
+			}
+		}
+		report.Updated++
+	}
+
+	return nil
+}
+
+func (i *Importer) findCinema(ctx context.Context, externalID string) (*types.Cinema, error) {
+	cinemas, err := i.store.Cinema.GetCinemas(ctx, db.Map{"externalID": externalID}, &db.Pagination{Page: 1, Limit: 1})
+	if err != nil || len(cinemas) == 0 {
+		return nil, err
+	}
+
+	return cinemas[0], nil
+}
@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// listSeparator separates the values of list columns in CSV files, e.g.
+// "action|thriller".
+const listSeparator = "|"
+
+type parsedRow[T any] struct {
+	line  int
+	value T
+	errs  map[string]string
+}
+
+func decodeRows[T any](r io.Reader, format Format) ([]parsedRow[T], error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV[T](r)
+	case FormatJSON:
+		return decodeJSON[T](r)
+	default:
+		return nil, fmt.Errorf("%w: the format should be csv or json", ErrInvalidFile)
+	}
+}
+
+// decodeJSON reads an array of rows. Rows are decoded one by one, so that a
+// row with a wrongly typed value does not fail the whole file.
+func decodeJSON[T any](r io.Reader) ([]parsedRow[T], error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: the file should be a JSON array of rows", ErrInvalidFile)
+	}
+
+	rows := make([]parsedRow[T], len(raw))
+	for i, msg := range raw {
+		rows[i].line = i + 1
+		rows[i].errs = map[string]string{}
+
+		dec := json.NewDecoder(bytes.NewReader(msg))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&rows[i].value); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && len(typeErr.Field) > 0 {
+				rows[i].errs[typeErr.Field] = fmt.Sprintf("%s should be %s", typeErr.Field, describeType(typeErr.Type))
+			} else {
+				rows[i].errs["row"] = strings.TrimPrefix(err.Error(), "json: ")
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// decodeCSV reads a header row naming the columns and a row per line. Column
+// names are matched case insensitively and their order does not matter.
+func decodeCSV[T any](r io.Reader) ([]parsedRow[T], error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: the file should start with a header row", ErrInvalidFile)
+	}
+
+	var (
+		fields  = csvFields(reflect.TypeOf(*new(T)))
+		columns = make([]csvField, len(header))
+	)
+	for i, name := range header {
+		// spreadsheet programs like to start files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidFile, header[i])
+		}
+		columns[i] = field
+	}
+
+	rows := []parsedRow[T]{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFile, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := parsedRow[T]{line: line, errs: map[string]string{}}
+		if len(record) > len(columns) {
+			row.errs["row"] = fmt.Sprintf("row has %d columns, the header %d", len(record), len(columns))
+			rows = append(rows, row)
+			continue
+		}
+
+		value := reflect.ValueOf(&row.value).Elem()
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if len(cell) == 0 {
+				continue
+			}
+
+			if err := setField(value.Field(columns[i].index), cell); err != nil {
+				row.errs[columns[i].name] = fmt.Sprintf("%s should be %s", columns[i].name, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+type csvField struct {
+	index int
+	name  string
+}
+
+// csvFields maps the lower case JSON names of the fields of a row type to
+// the fields.
+func csvFields(t reflect.Type) map[string]csvField {
+	fields := map[string]csvField{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		fields[strings.ToLower(name)] = csvField{index: i, name: name}
+	}
+
+	return fields
+}
+
+// describeType names the values of a field type the way the errors of
+// setField do.
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int:
+		return "a whole number"
+	case reflect.Float64:
+		return "a number"
+	case reflect.Pointer:
+		return describeType(t.Elem())
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "a " + t.Kind().String()
+	}
+}
+
+// setField parses a cell into a field of a row. The error describes the
+// value that was expected.
+func setField(field reflect.Value, cell string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Int:
+		n, err := strconv.Atoi(cell)
+		if err != nil {
+			return errors.New("a whole number")
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return errors.New("a number")
+		}
+		field.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), cell); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.Slice:
+		list := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, part := range strings.Split(cell, listSeparator) {
+			if part = strings.TrimSpace(part); len(part) == 0 {
+				continue
+			}
+
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, part); err != nil {
+				return errors.New("a list separated by " + listSeparator)
+			}
+			list = reflect.Append(list, elem)
+		}
+		field.Set(list)
+	default:
+		return fmt.Errorf("unsupported column type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// HallRow is a hall referring to its cinema and movie by their external IDs,
+// so they have to be imported first.
+type HallRow struct {
+	ExternalID string   `json:"externalID"`
+	Cinema     string   `json:"cinema"`
+	Movie      string   `json:"movie"`
+	Capacity   int      `json:"capacity"`
+	Price      float64  `json:"price"`
+	Features   []string `json:"features"`
+}
+
+func (i *Importer) importHalls(ctx context.Context, rows []parsedRow[HallRow], dryRun bool, report *Report) error {
+	var (
+		seen    = map[string]int{}
+		cinemas = map[string]*types.Cinema{}
+		movies  = map[string]*types.Movie{}
+	)
+
+	for _, row := range rows {
+		externalID := strings.TrimSpace(row.value.ExternalID)
+
+		cinema, err := cachedLookup(ctx, cinemas, strings.TrimSpace(row.value.Cinema), i.findCinema)
+		if err != nil {
+			return err
+		}
+		movie, err := cachedLookup(ctx, movies, strings.TrimSpace(row.value.Movie), i.findMovie)
+		if err != nil {
+			return err
+		}
+
+		params := types.CreateHallParams{
+			Capacity: row.value.Capacity,
+			Price:    row.value.Price,
+		}
+		for _, feature := range row.value.Features {
+			params.Features = append(params.Features, types.HallFeature(strings.ToLower(feature)))
+		}
+		errs := map[string]string{}
+		switch {
+		case cinema == nil:
+			errs["cinema"] = fmt.Sprintf("unknown cinema %q", row.value.Cinema)
+		case cinema.Retired:
+			errs["cinema"] = "cinema is retired"
+		default:
+			params.Cinema = cinema.ID.Hex()
+		}
+		if movie == nil {
+			errs["movie"] = fmt.Sprintf("unknown movie %q", row.value.Movie)
+		} else {
+			params.Movie = movie.ID.Hex()
+		}
+		errs = mergeErrors(mergeErrors(row.errs, errs), params.Validate())
+
+		var existing *types.Hall
+		if len(errs) == 0 && len(externalID) > 0 {
+			if existing, err = i.findHall(ctx, externalID); err != nil {
+				return err
+			}
+		}
+
+		// moving halls and taking away seats can affect bookings, which is
+		// left to the hall API that checks them
+		if existing != nil {
+			if existing.Cinema != cinema.ID {
+				errs["cinema"] = "halls can not be moved to another cinema by import"
+			}
+			if params.Capacity < existing.Capacity {
+				errs["capacity"] = "the capacity of halls can not be lowered by import"
+			}
+		}
+
+		if !checkRow(report, row.line, externalID, errs, seen) {
+			continue
+		}
+
+		if existing == nil {
+			if !dryRun {
+				hall := types.NewHallFromParams(params)
+				hall.ExternalID = externalID
+				hall.OrganizationID = cinema.OrganizationID
+				if _, err := i.store.Hall.InsertHall(ctx, hall); err != nil {
+					if mongo.IsDuplicateKeyError(err) {
+						report.fail(row.line, externalID, map[string]string{"externalID": "hall was imported concurrently"})
+						continue
+					}
+
+					return err
+				}
+			}
+			report.Created++
+			continue
+		}
+
+		if !dryRun {
+			update := db.Map{
+				"capacity": params.Capacity,
+				"price":    types.RoundMoney(params.Price),
+				"movie":    movie.ID,
+				"features": params.Features,
+			}
+			if err := i.store.Hall.UpdateHall(ctx, existing.ID, update); err != nil {
+				return err
+			}
+		}
+		report.Updated++
+	}
+
+	return nil
+}
+
+func (i *Importer) findHall(ctx context.Context, externalID string) (*types.Hall, error) {
+	halls, err := i.store.Hall.GetHalls(ctx, db.Map{"externalID": externalID}, &db.ListOptions{Pagination: db.Pagination{Page: 1, Limit: 1}})
+	if err != nil || len(halls) == 0 {
+		return nil, err
+	}
+
+	return halls[0], nil
+}
+
+// cachedLookup finds documents by external ID once per import, as many halls
+// share a cinema or movie.
+func cachedLookup[T any](ctx context.Context, cache map[string]*T, externalID string, find func(context.Context, string) (*T, error)) (*T, error) {
+	if len(externalID) == 0 {
+		return nil, nil
+	}
+
+	if doc, ok := cache[externalID]; ok {
+		return doc, nil
+	}
+
+	doc, err := find(ctx, externalID)
+	if err != nil {
+		return nil, err
+	}
+	cache[externalID] = doc
+
+	return doc, nil
+}
@@ -0,0 +1,163 @@
+// Package importer ingests catalogs of movies, cinemas and halls sent as CSV
+// or JSON files. Every row is validated on its own, valid rows are upserted
+// by their external ID and invalid rows are reported back.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindMovies  Kind = "movies"
+	KindCinemas Kind = "cinemas"
+	KindHalls   Kind = "halls"
+)
+
+func (k Kind) IsValid() bool {
+	return k == KindMovies || k == KindCinemas || k == KindHalls
+}
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// DetectFormat guesses the format of a file from its name or content type.
+func DetectFormat(name, contentType string) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatJSON
+	}
+
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return FormatCSV
+	case strings.Contains(contentType, "json"):
+		return FormatJSON
+	}
+
+	return ""
+}
+
+// ErrInvalidFile is returned when a file can not be read at all, as opposed
+// to files with invalid rows.
+var ErrInvalidFile = errors.New("invalid import file")
+
+type Options struct {
+	Kind   Kind
+	Format Format
+	// DryRun validates the file and reports what would be created and
+	// updated without writing anything.
+	DryRun bool
+}
+
+type RowError struct {
+	// Row is the line of the row in CSV files, counting the header, or its
+	// position in JSON files, counting from 1.
+	Row        int               `json:"row"`
+	ExternalID string            `json:"externalID,omitempty"`
+	Errors     map[string]string `json:"errors"`
+}
+
+type Report struct {
+	Kind    Kind       `json:"kind"`
+	DryRun  bool       `json:"dryRun"`
+	Rows    int        `json:"rows"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors"`
+}
+
+func (r *Report) fail(row int, externalID string, errs map[string]string) {
+	r.Failed++
+	r.Errors = append(r.Errors, RowError{Row: row, ExternalID: externalID, Errors: errs})
+}
+
+type Importer struct {
+	store *db.Store
+}
+
+func New(store *db.Store) *Importer {
+	return &Importer{
+		store: store,
+	}
+}
+
+func (i *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	report := &Report{
+		Kind:   opts.Kind,
+		DryRun: opts.DryRun,
+		Errors: []RowError{},
+	}
+
+	switch opts.Kind {
+	case KindMovies:
+		rows, err := decodeRows[MovieRow](r, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		report.Rows = len(rows)
+
+		return report, i.importMovies(ctx, rows, opts.DryRun, report)
+	case KindCinemas:
+		rows, err := decodeRows[CinemaRow](r, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		report.Rows = len(rows)
+
+		return report, i.importCinemas(ctx, rows, opts.DryRun, report)
+	case KindHalls:
+		rows, err := decodeRows[HallRow](r, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		report.Rows = len(rows)
+
+		return report, i.importHalls(ctx, rows, opts.DryRun, report)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidFile, opts.Kind)
+	}
+}
+
+// checkRow reports the row as failed if it has errors, has no external ID or
+// repeats the external ID of an earlier row.
+func checkRow(report *Report, line int, externalID string, errs map[string]string, seen map[string]int) bool {
+	if len(externalID) == 0 {
+		errs["externalID"] = "externalID is required"
+	} else if first, ok := seen[externalID]; ok {
+		errs["externalID"] = fmt.Sprintf("externalID is already used by row %d", first)
+	} else {
+		seen[externalID] = line
+	}
+
+	if len(errs) > 0 {
+		report.fail(line, externalID, errs)
+		return false
+	}
+
+	return true
+}
+
+func mergeErrors(dst, src map[string]string) map[string]string {
+	for field, msg := range src {
+		if _, ok := dst[field]; !ok {
+			dst[field] = msg
+		}
+	}
+
+	return dst
+}
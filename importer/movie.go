@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type MovieRow struct {
+	ExternalID string   `json:"externalID"`
+	Title      string   `json:"title"`
+	Genres     []string `json:"genres"`
+	Runtime    int      `json:"runtime"`
+	// ReleaseDate is a date like 2024-03-01.
+	ReleaseDate       string   `json:"releaseDate"`
+	Synopsis          string   `json:"synopsis"`
+	Director          string   `json:"director"`
+	Cast              []string `json:"cast"`
+	OriginalLanguage  string   `json:"originalLanguage"`
+	AudioLanguages    []string `json:"audioLanguages"`
+	SubtitleLanguages []string `json:"subtitleLanguages"`
+	PosterURL         string   `json:"posterURL"`
+	TrailerURL        string   `json:"trailerURL"`
+	Certification     string   `json:"certification"`
+}
+
+func (r MovieRow) params() (types.CreateMovieParams, map[string]string) {
+	params := types.CreateMovieParams{
+		Title: strings.TrimSpace(r.Title),
+		MovieMetadata: types.MovieMetadata{
+			Runtime:           r.Runtime,
+			Synopsis:          r.Synopsis,
+			Director:          r.Director,
+			Cast:              r.Cast,
+			OriginalLanguage:  r.OriginalLanguage,
+			AudioLanguages:    r.AudioLanguages,
+			SubtitleLanguages: r.SubtitleLanguages,
+			PosterURL:         r.PosterURL,
+			TrailerURL:        r.TrailerURL,
+			Certification:     r.Certification,
+		},
+	}
+
+	for _, genre := range r.Genres {
+		params.Genres = append(params.Genres, types.Genre(strings.ToLower(strings.TrimSpace(genre))))
+	}
+
+	errs := map[string]string{}
+	if len(r.ReleaseDate) > 0 {
+		date, err := time.Parse(dateLayout, r.ReleaseDate)
+		if err != nil {
+			errs["releaseDate"] = "releaseDate should be a date like 2024-03-01"
+		}
+		params.ReleaseDate = date
+	}
+
+	return params, mergeErrors(errs, params.Validate())
+}
+
+func (i *Importer) importMovies(ctx context.Context, rows []parsedRow[MovieRow], dryRun bool, report *Report) error {
+	genres, err := i.store.Genre.GetGenres(ctx, db.Map{})
+	if err != nil {
+		return err
+	}
+
+	known := map[types.Genre]bool{}
+	for _, genre := range genres {
+		known[genre.ID] = true
+	}
+
+	seen := map[string]int{}
+	for _, row := range rows {
+		externalID := strings.TrimSpace(row.value.ExternalID)
+
+		params, errs := row.value.params()
+		errs = mergeErrors(row.errs, errs)
+		for _, genre := range params.Genres {
+			if genre.IsValid() && !known[genre] {
+				errs["genres"] = fmt.Sprintf("unknown genre %s", genre)
+			}
+		}
+
+		if !checkRow(report, row.line, externalID, errs, seen) {
+			continue
+		}
+
+		existing, err := i.findMovie(ctx, externalID)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			if !dryRun {
+				movie := types.NewMovieFromParams(params)
+				movie.ExternalID = externalID
+				if _, err := i.store.Movie.InsertMovie(ctx, movie); err != nil {
+					if mongo.IsDuplicateKeyError(err) {
+						report.fail(row.line, externalID, map[string]string{"externalID": "movie was imported concurrently"})
+						continue
+					}
+
+					return err
+				}
+			}
+			report.Created++
+			continue
+		}
+
+		if !dryRun {
+			update := types.UpdateMovieParams{
+				Title:         params.Title,
+				Genres:        params.Genres,
+				MovieMetadata: params.MovieMetadata,
+			}.ToBSON()
+			if err := i.store.Movie.UpdateMovie(ctx, existing.ID.Hex(), db.Map(update)); err != nil {
+				return err
+			}
+		}
+		report.Updated++
+	}
+
+	return nil
+}
+
+func (i *Importer) findMovie(ctx context.Context, externalID string) (*types.Movie, error) {
+	movies, err := i.store.Movie.GetMovies(ctx, db.Map{"externalID": externalID}, &db.ListOptions{Pagination: db.Pagination{Page: 1, Limit: 1}})
+	if err != nil || len(movies) == 0 {
+		return nil, err
+	}
+
+	return movies[0], nil
+}
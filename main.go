@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/api"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/blob"
 	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
@@ -14,6 +16,8 @@ import (
 
 var config = fiber.Config{
 	ErrorHandler: api.ErrorHandler,
+	// leaves room for image uploads of up to 10 MB and the rest of the form
+	BodyLimit: 12 * 1024 * 1024,
 }
 
 func main() {
@@ -23,25 +27,62 @@ func main() {
 		log.Fatal(err)
 	}
 
+	blobs, err := blob.NewStoreFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	var (
-		userStore    = db.NewMongoUserStore(client)
-		cinemaStore  = db.NewMongoCinemaStore(client)
-		movieStore   = db.NewMongoMovieStore(client)
-		hallStore    = db.NewMongoHallStore(client, cinemaStore)
-		bookingStore = db.NewMongoBookingStore(client)
-		store        = &db.Store{
-			User:    userStore,
-			Cinema:  cinemaStore,
-			Movie:   movieStore,
-			Hall:    hallStore,
-			Booking: bookingStore,
+		userStore     = db.NewMongoUserStore(client)
+		cinemaStore   = db.NewMongoCinemaStore(client)
+		movieStore    = db.NewMongoMovieStore(client)
+		hallStore     = db.NewMongoHallStore(client, cinemaStore)
+		bookingStore  = db.NewMongoBookingStore(client)
+		taxStore      = db.NewMongoTaxStore(client)
+		genreStore    = db.NewMongoGenreStore(client)
+		orgStore      = db.NewMongoOrganizationStore(client)
+		invoiceStore  = db.NewMongoInvoiceStore(client)
+		reviewStore   = db.NewMongoReviewStore(client)
+		feedbackStore = db.NewMongoFeedbackStore(client)
+		store         = &db.Store{
+			User:         userStore,
+			Cinema:       cinemaStore,
+			Movie:        movieStore,
+			Hall:         hallStore,
+			Booking:      bookingStore,
+			Tax:          taxStore,
+			Invoice:      invoiceStore,
+			GiftCard:     db.NewMongoGiftCardStore(client),
+			Wallet:       db.NewMongoWalletStore(client),
+			Loyalty:      db.NewMongoLoyaltyStore(client),
+			Subscription: db.NewMongoSubscriptionStore(client),
+			Concession:   db.NewMongoConcessionStore(client),
+			Genre:        genreStore,
+			Showtime:     db.NewMongoShowtimeStore(client),
+			Review:       reviewStore,
+			Feedback:     feedbackStore,
+			Organization: orgStore,
 		}
-		userHandler    = api.NewUserHandler(store)
-		cinemaHandler  = api.NewCinemaHandler(store)
-		movieHandler   = api.NewMovieHandler(store)
-		hallHandler    = api.NewHallHandler(store)
-		authHandler    = api.NewAuthHandler(userStore)
-		bookingHandler = api.NewBookingHandler(store)
+		userHandler         = api.NewUserHandler(store)
+		cinemaHandler       = api.NewCinemaHandler(store, blobs)
+		movieHandler        = api.NewMovieHandler(store, blobs)
+		hallHandler         = api.NewHallHandler(store)
+		authHandler         = api.NewAuthHandler(userStore)
+		bookingHandler      = api.NewBookingHandler(store)
+		taxHandler          = api.NewTaxHandler(store)
+		giftCardHandler     = api.NewGiftCardHandler(store)
+		walletHandler       = api.NewWalletHandler(store)
+		loyaltyHandler      = api.NewLoyaltyHandler(store)
+		subscriptionHandler = api.NewSubscriptionHandler(store)
+		concessionHandler   = api.NewConcessionHandler(store)
+		genreHandler        = api.NewGenreHandler(store)
+		searchHandler       = api.NewSearchHandler(store)
+		showtimeHandler     = api.NewShowtimeHandler(store)
+		reviewHandler       = api.NewReviewHandler(store)
+		feedbackHandler     = api.NewFeedbackHandler(store)
+		mediaHandler        = api.NewMediaHandler(store, blobs)
+		importHandler       = api.NewImportHandler(store)
+		organizationHandler = api.NewOrganizationHandler(store)
 
 		app   = fiber.New(config)
 		auth  = app.Group("/api")
@@ -49,8 +90,34 @@ func main() {
 		admin = apiV1.Group("/admin", api.AdminAuth)
 	)
 
+	checkIndexes(userStore.MigrateEmails(context.Background()))
+	checkIndexes(userStore.EnsureIndexes(context.Background()))
+	if err := genreStore.SeedDefaultGenres(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	if err := movieStore.MigrateLegacyGenres(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	checkIndexes(movieStore.EnsureIndexes(context.Background()))
+	if err := cinemaStore.MigrateLegacyRatings(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	checkIndexes(cinemaStore.EnsureIndexes(context.Background()))
+	checkIndexes(hallStore.EnsureIndexes(context.Background()))
+	checkIndexes(orgStore.EnsureIndexes(context.Background()))
+	checkIndexes(invoiceStore.EnsureIndexes(context.Background()))
+	checkIndexes(taxStore.EnsureIndexes(context.Background()))
+	checkIndexes(reviewStore.EnsureIndexes(context.Background()))
+	checkIndexes(feedbackStore.EnsureIndexes(context.Background()))
+
+	// uploads kept on the local filesystem are served by the API itself
+	if local, ok := blobs.(*blob.LocalStore); ok {
+		app.Static(local.MountPath(), local.Dir())
+	}
+
 	// Auth routes
 	auth.Post("/auth", authHandler.HandleAuthenticate)
+	auth.Post("/register", authHandler.HandleRegister)
 
 	// Versioned API routes
 	// User routes
@@ -64,17 +131,101 @@ func main() {
 	apiV1.Get("/cinema", cinemaHandler.HandleGetCinemas)
 	apiV1.Get("/cinema/:id", cinemaHandler.HandleGetCinema)
 	apiV1.Get("/cinema/:id/halls", cinemaHandler.HandleGetHalls)
+	apiV1.Get("/cinema/:id/concessions", concessionHandler.HandleGetConcessions)
+	apiV1.Get("/cinema/:id/feedback", feedbackHandler.HandleGetFeedback)
+	admin.Post("/cinema", cinemaHandler.HandlePostCinema)
+	admin.Put("/cinema/:id", cinemaHandler.HandlePutCinema)
+	admin.Delete("/cinema/:id", cinemaHandler.HandleRetireCinema)
+	admin.Post("/cinema/:id/photos", mediaHandler.HandlePostCinemaPhoto)
+	admin.Delete("/cinema/:id/photos/:imageID", mediaHandler.HandleDeleteCinemaPhoto)
 
 	apiV1.Get("/hall", hallHandler.HandleGetHalls)
 	apiV1.Post("/hall/:id/book", hallHandler.HandleBookHall)
+	apiV1.Post("/quote", hallHandler.HandleQuote)
+	admin.Post("/hall", hallHandler.HandlePostHall)
+	admin.Put("/hall/:id", hallHandler.HandlePutHall)
+	admin.Delete("/hall/:id", hallHandler.HandleDeleteHall)
 
+	apiV1.Get("/movie/now-showing", showtimeHandler.HandleGetNowShowing)
+	apiV1.Get("/movie/coming-soon", showtimeHandler.HandleGetComingSoon)
 	apiV1.Get("/movie/:id", movieHandler.HandleGetMovie)
 	apiV1.Get("/movie", movieHandler.HandleGetMovies)
+	admin.Post("/movie", api.PlatformAdminAuth, movieHandler.HandlePostMovie)
+	admin.Put("/movie/:id", api.PlatformAdminAuth, movieHandler.HandlePutMovie)
+	admin.Delete("/movie/:id", api.PlatformAdminAuth, movieHandler.HandleDeleteMovie)
+	admin.Post("/movie/:id/poster", api.PlatformAdminAuth, mediaHandler.HandlePostMoviePoster)
+	admin.Post("/movie/:id/stills", api.PlatformAdminAuth, mediaHandler.HandlePostMovieStill)
+	admin.Delete("/movie/:id/stills/:imageID", api.PlatformAdminAuth, mediaHandler.HandleDeleteMovieStill)
+
+	// Review routes
+	apiV1.Get("/movie/:id/reviews", reviewHandler.HandleGetReviews)
+	apiV1.Post("/movie/:id/reviews", reviewHandler.HandlePostReview)
+	admin.Post("/review/:id/hide", api.PlatformAdminAuth, reviewHandler.HandleHideReview)
+	admin.Post("/review/:id/restore", api.PlatformAdminAuth, reviewHandler.HandleRestoreReview)
+
+	// Search routes
+	apiV1.Get("/search", searchHandler.HandleSearch)
+
+	// Schedule and showtime routes
+	admin.Get("/schedule", showtimeHandler.HandleGetSchedules)
+	admin.Post("/schedule", showtimeHandler.HandlePostSchedule)
+	admin.Delete("/schedule/:id", showtimeHandler.HandleDeleteSchedule)
+	apiV1.Get("/showtime", showtimeHandler.HandleGetShowtimes)
+	apiV1.Get("/run", showtimeHandler.HandleGetRunWindows)
+	admin.Post("/run", showtimeHandler.HandlePostRunWindow)
+	admin.Delete("/run/:id", showtimeHandler.HandleDeleteRunWindow)
+
+	// Genre routes
+	apiV1.Get("/genre", genreHandler.HandleGetGenres)
+	admin.Post("/genre", api.PlatformAdminAuth, genreHandler.HandlePostGenre)
+	admin.Put("/genre/:id", api.PlatformAdminAuth, genreHandler.HandlePutGenre)
+	admin.Delete("/genre/:id", api.PlatformAdminAuth, genreHandler.HandleDeleteGenre)
 
 	// Booking routes
 	admin.Get("/booking", bookingHandler.HandleGetBookings)
 	apiV1.Get("/booking/:id", bookingHandler.HandleGetBooking)
 	apiV1.Get("/booking/:id/cancel", bookingHandler.HandleCancelBooking)
+	admin.Post("/booking/:id/capture", bookingHandler.HandleCapturePayment)
+	apiV1.Get("/booking/:id/invoice", bookingHandler.HandleGetInvoice)
+	apiV1.Post("/booking/:id/feedback", feedbackHandler.HandlePostFeedback)
+
+	// Tax routes
+	admin.Get("/tax", api.PlatformAdminAuth, taxHandler.HandleGetTaxRates)
+	admin.Post("/tax", api.PlatformAdminAuth, taxHandler.HandlePostTaxRate)
+	admin.Delete("/tax/:id", api.PlatformAdminAuth, taxHandler.HandleDeleteTaxRate)
+
+	// Gift card and wallet routes
+	admin.Get("/giftcard", api.PlatformAdminAuth, giftCardHandler.HandleGetGiftCards)
+	admin.Post("/giftcard", api.PlatformAdminAuth, giftCardHandler.HandlePostGiftCard)
+	apiV1.Get("/giftcard/:code", giftCardHandler.HandleGetGiftCard)
+	apiV1.Get("/me/wallet", walletHandler.HandleGetWallet)
+
+	// Loyalty routes
+	apiV1.Get("/me/loyalty", loyaltyHandler.HandleGetLoyalty)
+	admin.Get("/loyalty", api.PlatformAdminAuth, loyaltyHandler.HandleGetProgram)
+	admin.Put("/loyalty", api.PlatformAdminAuth, loyaltyHandler.HandlePutProgram)
+
+	// Subscription routes
+	admin.Post("/plan", api.PlatformAdminAuth, subscriptionHandler.HandlePostPlan)
+	apiV1.Get("/plan", subscriptionHandler.HandleGetPlans)
+	apiV1.Post("/subscription", subscriptionHandler.HandlePostSubscription)
+	apiV1.Get("/me/subscription", subscriptionHandler.HandleGetSubscriptions)
+	apiV1.Get("/subscription/:id/cancel", subscriptionHandler.HandleCancelSubscription)
+	admin.Post("/subscription/:id/capture", api.PlatformAdminAuth, subscriptionHandler.HandleCapturePayment)
+
+	// Concession routes
+	admin.Post("/concession", concessionHandler.HandlePostConcession)
+	admin.Put("/concession/:id", concessionHandler.HandlePutConcession)
+	admin.Post("/booking/:id/pickup", concessionHandler.HandlePickup)
+
+	// Organization routes
+	admin.Get("/organization", api.PlatformAdminAuth, organizationHandler.HandleGetOrganizations)
+	admin.Post("/organization", api.PlatformAdminAuth, organizationHandler.HandlePostOrganization)
+	admin.Get("/organization/:id", api.PlatformAdminAuth, organizationHandler.HandleGetOrganization)
+	admin.Post("/organization/:id/admins", api.PlatformAdminAuth, organizationHandler.HandlePostOrganizationAdmin)
+
+	// Import routes
+	admin.Post("/import/:kind", api.PlatformAdminAuth, importHandler.HandleImport)
 
 	listenAddr := os.Getenv("HTTP_LISTEN_ADDRESS")
 	app.Listen(listenAddr)
@@ -85,3 +236,17 @@ func init() {
 		log.Fatal(err)
 	}
 }
+
+// checkIndexes stops the server if the indexes could not be created. Unique
+// indexes that existing documents conflict with are only reported, the API
+// keeps working without them until the documents are cleaned up.
+func checkIndexes(err error) {
+	var conflict *db.IndexConflictError
+	if errors.As(err, &conflict) {
+		log.Println("Warning:", err)
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
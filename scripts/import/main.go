@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/db"
+	"github.com/cmkqwerty/movie-ticket-booking-backend/importer"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+)
+
+// Imports a catalog file, e.g.
+//
+//	go run scripts/import/main.go -kind movies -file movies.csv -dry-run
+func main() {
+	var (
+		kind   = flag.String("kind", "", "what the file contains: movies, cinemas or halls")
+		file   = flag.String("file", "", "the CSV or JSON file to import")
+		format = flag.String("format", "", "csv or json, defaults to the file extension")
+		dryRun = flag.Bool("dry-run", false, "validate and report without writing anything")
+	)
+	flag.Parse()
+
+	if !importer.Kind(*kind).IsValid() || len(*file) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatal(err)
+	}
+	var (
+		ctx           = context.Background()
+		mongoEndpoint = os.Getenv("MONGO_DB_URL")
+	)
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoEndpoint))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		cinemaStore = db.NewMongoCinemaStore(client)
+		movieStore  = db.NewMongoMovieStore(client)
+		hallStore   = db.NewMongoHallStore(client, cinemaStore)
+	)
+	if err := cinemaStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := movieStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := hallStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	store := &db.Store{
+		Cinema: cinemaStore,
+		Movie:  movieStore,
+		Hall:   hallStore,
+		Genre:  db.NewMongoGenreStore(client),
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	opts := importer.Options{
+		Kind:   importer.Kind(*kind),
+		Format: importer.Format(*format),
+		DryRun: *dryRun,
+	}
+	if len(opts.Format) == 0 {
+		opts.Format = importer.DetectFormat(*file, "")
+	}
+
+	report, err := importer.New(store).Import(ctx, f, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -34,21 +34,59 @@ func main() {
 		log.Fatal(err)
 	}
 
-	cinemaStore := db.NewMongoCinemaStore(client)
+	var (
+		cinemaStore = db.NewMongoCinemaStore(client)
+		movieStore  = db.NewMongoMovieStore(client)
+		hallStore   = db.NewMongoHallStore(client, cinemaStore)
+	)
+	if err := cinemaStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := movieStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := hallStore.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
 	store := db.Store{
-		User:    db.NewMongoUserStore(client),
-		Cinema:  cinemaStore,
-		Movie:   db.NewMongoMovieStore(client),
-		Hall:    db.NewMongoHallStore(client, cinemaStore),
-		Booking: db.NewMongoBookingStore(client),
+		User:         db.NewMongoUserStore(client),
+		Cinema:       cinemaStore,
+		Movie:        movieStore,
+		Hall:         hallStore,
+		Booking:      db.NewMongoBookingStore(client),
+		Tax:          db.NewMongoTaxStore(client),
+		Invoice:      db.NewMongoInvoiceStore(client),
+		GiftCard:     db.NewMongoGiftCardStore(client),
+		Wallet:       db.NewMongoWalletStore(client),
+		Loyalty:      db.NewMongoLoyaltyStore(client),
+		Subscription: db.NewMongoSubscriptionStore(client),
+		Concession:   db.NewMongoConcessionStore(client),
+		Genre:        db.NewMongoGenreStore(client),
+		Showtime:     db.NewMongoShowtimeStore(client),
+		Review:       db.NewMongoReviewStore(client),
+		Feedback:     db.NewMongoFeedbackStore(client),
+		Organization: db.NewMongoOrganizationStore(client),
+	}
+
+	for _, genre := range types.DefaultGenres() {
+		fixtures.AddGenre(&store, genre.ID, genre.Name)
 	}
 
 	user := fixtures.AddUser(&store, "Jimmy", "Scott", false)
 	fmt.Println("jimmy ->", api.CreateTokenFromUser(user))
 	admin := fixtures.AddUser(&store, "Admin", "Admin", true)
 	fmt.Println("admin ->", api.CreateTokenFromUser(admin))
-	cinema := fixtures.AddCinema(&store, "CinemaxX", "Berlin", 5, nil)
-	movie := fixtures.AddMovie(&store, "The Dark Knight", types.Action)
+	cinema := fixtures.AddCinema(&store, "CinemaxX", "Berlin", nil)
+	potsdamerPlatz := types.NewGeoPoint(types.Coordinates{Lat: 52.5096, Lng: 13.3736})
+	if err := store.Cinema.UpdateCinema(ctx, db.Map{"_id": cinema.ID}, db.Map{"$set": db.Map{"geo": potsdamerPlatz}}); err != nil {
+		log.Fatal(err)
+	}
+	fixtures.AddTaxRate(&store, "MwSt. 7%", "", cinema.ID, types.ProductTicket, 7)
+	fixtures.AddTaxRate(&store, "MwSt. 19%", "", cinema.ID, types.ProductConcession, 19)
+	fixtures.AddConcession(&store, cinema.ID, "Popcorn (large)", 6.5, 200)
+	fixtures.AddConcession(&store, cinema.ID, "Soft drink (0.5l)", 4.0, 300)
+	movie := fixtures.AddMovie(&store, "The Dark Knight", types.Action, types.Thriller)
 	hall := fixtures.AddHall(&store, 100, 10.0, cinema.ID, movie.ID)
 	booking := fixtures.AddBooking(&store, user.ID, hall.ID, types.Night, time.Now().AddDate(0, 0, 5))
 	fmt.Println(booking)
@@ -56,6 +94,9 @@ func main() {
 	for i := 0; i < 100; i++ {
 		name := fmt.Sprintf("Cinema%d", i)
 		location := fmt.Sprintf("Location%d", i)
-		fixtures.AddCinema(&store, name, location, rand.Intn(5), nil)
+		c := fixtures.AddCinema(&store, name, location, nil)
+		if err := store.Cinema.AddCinemaRating(ctx, c.ID, rand.Intn(5)+1); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
@@ -15,10 +15,15 @@ const (
 )
 
 type Booking struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID   primitive.ObjectID `bson:"userID,omitempty" json:"userID,omitempty"`
-	HallID   primitive.ObjectID `bson:"hallID,omitempty" json:"hallID,omitempty"`
-	Session  Session            `bson:"session,omitempty" json:"session,omitempty"`
-	Date     time.Time          `bson:"date,omitempty" json:"date,omitempty"`
-	Canceled bool               `bson:"canceled" json:"canceled"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID         primitive.ObjectID `bson:"userID,omitempty" json:"userID,omitempty"`
+	HallID         primitive.ObjectID `bson:"hallID,omitempty" json:"hallID,omitempty"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	MovieID        primitive.ObjectID `bson:"movieID,omitempty" json:"movieID,omitempty"`
+	Session        Session            `bson:"session,omitempty" json:"session,omitempty"`
+	Date           time.Time          `bson:"date,omitempty" json:"date,omitempty"`
+	Canceled       bool               `bson:"canceled" json:"canceled"`
+	Price          *PriceBreakdown    `bson:"price,omitempty" json:"price,omitempty"`
+	Payments       []Payment          `bson:"payments,omitempty" json:"payments,omitempty"`
+	AddOns         []AddOn            `bson:"addOns,omitempty" json:"addOns,omitempty"`
 }
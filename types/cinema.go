@@ -1,35 +1,257 @@
 package types
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
 
-type Cinema struct {
-	ID       primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
-	Name     string               `bson:"name" json:"name"`
-	Location string               `bson:"location" json:"location"`
-	Halls    []primitive.ObjectID `bson:"halls" json:"halls"`
-	Rating   int                  `bson:"rating" json:"rating"`
+const (
+	minCinemaNameLen  = 2
+	maxCinemaNameLen  = 100
+	maxDescriptionLen = 2000
+)
+
+type CreateCinemaParams struct {
+	Name         string                       `json:"name"`
+	Description  string                       `json:"description"`
+	Location     string                       `json:"location"`
+	Address      *Address                     `json:"address"`
+	Coordinates  *Coordinates                 `json:"coordinates"`
+	OpeningHours []OpeningHours               `json:"openingHours"`
+	Amenities    []Amenity                    `json:"amenities"`
+	Contact      *Contact                     `json:"contact"`
+	Country      string                       `json:"country"`
+	Currency     string                       `json:"currency"`
+	Surcharges   map[HallFeature]float64      `json:"surcharges"`
+	Translations map[Locale]CinemaTranslation `json:"translations"`
+	// OrganizationID is the cinema chain operating the cinema. It is only
+	// chosen by platform admins, the cinemas of organization admins always
+	// belong to their organization.
+	OrganizationID string `json:"organizationID"`
+}
+
+func (p CreateCinemaParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(p.Name) < minCinemaNameLen || len(p.Name) > maxCinemaNameLen {
+		errs["name"] = fmt.Sprintf("name should be between %d and %d characters", minCinemaNameLen, maxCinemaNameLen)
+	}
+
+	if len(strings.TrimSpace(p.Location)) == 0 && p.Address == nil {
+		errs["location"] = "location or address is required"
+	}
+
+	if p.Address != nil {
+		p.Address.validate(errs)
+	}
+
+	if p.Coordinates != nil && !p.Coordinates.IsValid() {
+		errs["coordinates"] = "coordinates should be a valid latitude and longitude"
+	}
+
+	validateOpeningHours(p.OpeningHours, errs)
+	validateAmenities(p.Amenities, errs)
+
+	if p.Contact != nil {
+		p.Contact.validate(errs)
+	}
+
+	if len(p.Country) > 0 && len(p.Country) != 2 {
+		errs["country"] = "country should be a two letter ISO code"
+	}
+
+	if len(p.Currency) > 0 && len(p.Currency) != 3 {
+		errs["currency"] = "currency should be a three letter ISO code"
+	}
+
+	if len(p.Description) > maxDescriptionLen {
+		errs["description"] = fmt.Sprintf("description should be at most %d characters", maxDescriptionLen)
+	}
+
+	validateSurcharges(p.Surcharges, errs)
+	validateCinemaTranslations(p.Translations, errs)
+
+	if len(p.OrganizationID) > 0 && !primitive.IsValidObjectID(p.OrganizationID) {
+		errs["organizationID"] = "invalid organizationID"
+	}
+
+	return errs
+}
+
+func NewCinemaFromParams(params CreateCinemaParams) *Cinema {
+	currency := strings.ToUpper(params.Currency)
+	if len(currency) == 0 {
+		currency = DefaultCurrency
+	}
+
+	cinema := &Cinema{
+		Name:         params.Name,
+		Description:  params.Description,
+		Location:     params.Location,
+		Address:      params.Address,
+		OpeningHours: normalizeOpeningHours(params.OpeningHours),
+		Amenities:    params.Amenities,
+		Contact:      params.Contact,
+		Country:      strings.ToUpper(params.Country),
+		Currency:     currency,
+		Surcharges:   params.Surcharges,
+		Translations: params.Translations,
+		Halls:        []primitive.ObjectID{},
+	}
+
+	if len(strings.TrimSpace(cinema.Location)) == 0 {
+		cinema.Location = params.Address.String()
+	}
+
+	if params.Coordinates != nil {
+		cinema.Geo = NewGeoPoint(*params.Coordinates)
+	}
+
+	cinema.OrganizationID, _ = primitive.ObjectIDFromHex(params.OrganizationID)
+
+	return cinema
 }
 
-type Hall struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Capacity int                `bson:"capacity" json:"capacity"`
-	Price    float64            `bson:"price" json:"price"`
-	Movie    primitive.ObjectID `bson:"movie" json:"movie"`
-	Cinema   primitive.ObjectID `bson:"cinema" json:"cinema"`
+type UpdateCinemaParams struct {
+	Name         string                       `json:"name"`
+	Description  string                       `json:"description"`
+	Location     string                       `json:"location"`
+	Address      *Address                     `json:"address"`
+	Coordinates  *Coordinates                 `json:"coordinates"`
+	OpeningHours []OpeningHours               `json:"openingHours"`
+	Amenities    []Amenity                    `json:"amenities"`
+	Contact      *Contact                     `json:"contact"`
+	Country      string                       `json:"country"`
+	Currency     string                       `json:"currency"`
+	Surcharges   map[HallFeature]float64      `json:"surcharges"`
+	Translations map[Locale]CinemaTranslation `json:"translations"`
 }
 
-type Genre int
+func (p UpdateCinemaParams) Validate() map[string]string {
+	errs := map[string]string{}
 
-const (
-	Action Genre = iota
-	Comedy
-	Drama
-	Horror
-	Thriller
-)
+	if len(p.Name) > 0 && (len(p.Name) < minCinemaNameLen || len(p.Name) > maxCinemaNameLen) {
+		errs["name"] = fmt.Sprintf("name should be between %d and %d characters", minCinemaNameLen, maxCinemaNameLen)
+	}
 
-type Movie struct {
-	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Title string             `bson:"title" json:"title"`
-	Genre Genre              `bson:"genre" json:"genre"`
+	if p.Address != nil {
+		p.Address.validate(errs)
+	}
+
+	if p.Coordinates != nil && !p.Coordinates.IsValid() {
+		errs["coordinates"] = "coordinates should be a valid latitude and longitude"
+	}
+
+	validateOpeningHours(p.OpeningHours, errs)
+	validateAmenities(p.Amenities, errs)
+
+	if p.Contact != nil {
+		p.Contact.validate(errs)
+	}
+
+	if len(p.Country) > 0 && len(p.Country) != 2 {
+		errs["country"] = "country should be a two letter ISO code"
+	}
+
+	if len(p.Currency) > 0 && len(p.Currency) != 3 {
+		errs["currency"] = "currency should be a three letter ISO code"
+	}
+
+	if len(p.Description) > maxDescriptionLen {
+		errs["description"] = fmt.Sprintf("description should be at most %d characters", maxDescriptionLen)
+	}
+
+	validateSurcharges(p.Surcharges, errs)
+	validateCinemaTranslations(p.Translations, errs)
+
+	return errs
+}
+
+func (p UpdateCinemaParams) ToBSON() bson.M {
+	m := bson.M{}
+
+	if len(p.Name) > 0 {
+		m["name"] = p.Name
+	}
+
+	if len(p.Description) > 0 {
+		m["description"] = p.Description
+	}
+
+	if len(strings.TrimSpace(p.Location)) > 0 {
+		m["location"] = p.Location
+	}
+
+	if p.Address != nil {
+		m["address"] = p.Address
+
+		// the location follows the address, as when the cinema was created
+		if _, ok := m["location"]; !ok {
+			m["location"] = p.Address.String()
+		}
+	}
+
+	if p.Coordinates != nil {
+		m["geo"] = NewGeoPoint(*p.Coordinates)
+	}
+
+	if p.OpeningHours != nil {
+		m["openingHours"] = normalizeOpeningHours(p.OpeningHours)
+	}
+
+	if p.Amenities != nil {
+		m["amenities"] = p.Amenities
+	}
+
+	if p.Contact != nil {
+		m["contact"] = p.Contact
+	}
+
+	if len(p.Country) > 0 {
+		m["country"] = strings.ToUpper(p.Country)
+	}
+
+	if len(p.Currency) > 0 {
+		m["currency"] = strings.ToUpper(p.Currency)
+	}
+
+	if p.Surcharges != nil {
+		m["surcharges"] = p.Surcharges
+	}
+
+	translationsToBSON(p.Translations, m)
+
+	return m
+}
+
+type Cinema struct {
+	ID             primitive.ObjectID      `bson:"_id,omitempty" json:"id,omitempty"`
+	ExternalID     string                  `bson:"externalID,omitempty" json:"externalID,omitempty"`
+	OrganizationID primitive.ObjectID      `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	Name           string                  `bson:"name" json:"name"`
+	Description    string                  `bson:"description,omitempty" json:"description,omitempty"`
+	Location       string                  `bson:"location" json:"location"`
+	Address        *Address                `bson:"address,omitempty" json:"address,omitempty"`
+	Geo            *GeoPoint               `bson:"geo,omitempty" json:"geo,omitempty"`
+	OpeningHours   []OpeningHours          `bson:"openingHours,omitempty" json:"openingHours,omitempty"`
+	Amenities      []Amenity               `bson:"amenities,omitempty" json:"amenities,omitempty"`
+	Contact        *Contact                `bson:"contact,omitempty" json:"contact,omitempty"`
+	Photos         []Image                 `bson:"photos,omitempty" json:"photos,omitempty"`
+	Country        string                  `bson:"country" json:"country"`
+	Currency       string                  `bson:"currency" json:"currency"`
+	Surcharges     map[HallFeature]float64 `bson:"surcharges,omitempty" json:"surcharges,omitempty"`
+	Halls          []primitive.ObjectID    `bson:"halls" json:"halls"`
+	// Translations hold the description in other locales than the default
+	// one.
+	Translations map[Locale]CinemaTranslation `bson:"translations,omitempty" json:"translations,omitempty"`
+	// Rating is computed from the feedback customers give after their
+	// visits.
+	Rating RatingSummary `bson:"rating" json:"rating"`
+	// Retired cinemas are closed for good. They are kept for invoices and
+	// booking history but can not be booked anymore.
+	Retired   bool      `bson:"retired" json:"retired"`
+	RetiredAt time.Time `bson:"retiredAt,omitempty" json:"retiredAt,omitempty"`
 }
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+const maxAddOnQuantity = 20
+
+// Concession is an item of a cinema's snack and drinks catalog.
+type Concession struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	CinemaID       primitive.ObjectID `bson:"cinemaID" json:"cinemaID"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	Name           string             `bson:"name" json:"name"`
+	Price          float64            `bson:"price" json:"price"`
+	Stock          int                `bson:"stock" json:"stock"`
+	Active         bool               `bson:"active" json:"active"`
+}
+
+type CreateConcessionParams struct {
+	CinemaID string  `json:"cinemaID"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Stock    int     `json:"stock"`
+}
+
+func (p CreateConcessionParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if !primitive.IsValidObjectID(p.CinemaID) {
+		errs["cinemaID"] = "invalid cinemaID"
+	}
+
+	if len(p.Name) == 0 {
+		errs["name"] = "name is required"
+	}
+
+	if p.Price <= 0 {
+		errs["price"] = "price should be positive"
+	}
+
+	if p.Stock < 0 {
+		errs["stock"] = "stock should not be negative"
+	}
+
+	return errs
+}
+
+func NewConcessionFromParams(params CreateConcessionParams) *Concession {
+	cinemaID, _ := primitive.ObjectIDFromHex(params.CinemaID)
+
+	return &Concession{
+		CinemaID: cinemaID,
+		Name:     params.Name,
+		Price:    RoundMoney(params.Price),
+		Stock:    params.Stock,
+		Active:   true,
+	}
+}
+
+type UpdateConcessionParams struct {
+	Name   string   `json:"name"`
+	Price  *float64 `json:"price"`
+	Stock  *int     `json:"stock"`
+	Active *bool    `json:"active"`
+}
+
+func (p UpdateConcessionParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.Price != nil && *p.Price <= 0 {
+		errs["price"] = "price should be positive"
+	}
+
+	if p.Stock != nil && *p.Stock < 0 {
+		errs["stock"] = "stock should not be negative"
+	}
+
+	return errs
+}
+
+func (p UpdateConcessionParams) ToBSON() bson.M {
+	m := bson.M{}
+
+	if len(p.Name) > 0 {
+		m["name"] = p.Name
+	}
+
+	if p.Price != nil {
+		m["price"] = RoundMoney(*p.Price)
+	}
+
+	if p.Stock != nil {
+		m["stock"] = *p.Stock
+	}
+
+	if p.Active != nil {
+		m["active"] = *p.Active
+	}
+
+	return m
+}
+
+type AddOnParams struct {
+	ConcessionID string `json:"concessionID"`
+	Quantity     int    `json:"quantity"`
+}
+
+func (p AddOnParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if !primitive.IsValidObjectID(p.ConcessionID) {
+		errs["concessionID"] = "invalid concessionID"
+	}
+
+	if p.Quantity <= 0 || p.Quantity > maxAddOnQuantity {
+		errs["quantity"] = fmt.Sprintf("quantity should be between 1 and %d", maxAddOnQuantity)
+	}
+
+	return errs
+}
+
+// AddOn is a concession pre-ordered with a booking and picked up at the
+// cinema.
+type AddOn struct {
+	ConcessionID primitive.ObjectID `bson:"concessionID" json:"concessionID"`
+	Name         string             `bson:"name" json:"name"`
+	Quantity     int                `bson:"quantity" json:"quantity"`
+	UnitPrice    float64            `bson:"unitPrice" json:"unitPrice"`
+	Collected    bool               `bson:"collected" json:"collected"`
+	CollectedAt  time.Time          `bson:"collectedAt,omitempty" json:"collectedAt,omitempty"`
+}
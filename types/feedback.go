@@ -0,0 +1,56 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+const maxFeedbackLength = 1000
+
+// Feedback is the rating a customer gives a cinema after a visit. Every
+// booking can be rated once.
+type Feedback struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	CinemaID       primitive.ObjectID `bson:"cinemaID" json:"cinemaID"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	UserID         primitive.ObjectID `bson:"userID" json:"-"`
+	BookingID      primitive.ObjectID `bson:"bookingID" json:"-"`
+	Author         string             `bson:"author" json:"author"`
+	Stars          int                `bson:"stars" json:"stars"`
+	Comment        string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type CreateFeedbackParams struct {
+	Stars   int    `json:"stars"`
+	Comment string `json:"comment"`
+}
+
+func (p CreateFeedbackParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.Stars < minStars || p.Stars > maxStars {
+		errs["stars"] = fmt.Sprintf("stars should be between %d and %d", minStars, maxStars)
+	}
+
+	if len(p.Comment) > maxFeedbackLength {
+		errs["comment"] = fmt.Sprintf("comment should be at most %d characters", maxFeedbackLength)
+	}
+
+	return errs
+}
+
+func NewFeedbackFromParams(params CreateFeedbackParams, booking *Booking, hall *Hall, user *User) *Feedback {
+	return &Feedback{
+		CinemaID:       hall.Cinema,
+		OrganizationID: hall.OrganizationID,
+		UserID:         user.ID,
+		BookingID:      booking.ID,
+		Author:         authorName(user),
+		Stars:          params.Stars,
+		Comment:        strings.TrimSpace(params.Comment),
+		CreatedAt:      time.Now().UTC(),
+	}
+}
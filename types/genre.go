@@ -0,0 +1,96 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxGenreNameLen = 50
+
+var genreIDPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,29}$`)
+
+// Genre is the stable id of a genre in the genre catalog, e.g. "sci-fi". It
+// is what movies store and clients send, display names live in the catalog.
+type Genre string
+
+const (
+	Action   Genre = "action"
+	Comedy   Genre = "comedy"
+	Drama    Genre = "drama"
+	Horror   Genre = "horror"
+	Thriller Genre = "thriller"
+)
+
+// LegacyGenres maps the numeric genres movies used to be stored with to
+// their ids.
+var LegacyGenres = []Genre{Action, Comedy, Drama, Horror, Thriller}
+
+func (g Genre) IsValid() bool {
+	return genreIDPattern.MatchString(string(g))
+}
+
+// GenreInfo is an entry of the admin managed genre catalog.
+type GenreInfo struct {
+	ID   Genre  `bson:"_id" json:"id"`
+	Name string `bson:"name" json:"name"`
+}
+
+// DefaultGenres are the genres a new catalog starts with.
+func DefaultGenres() []*GenreInfo {
+	return []*GenreInfo{
+		{ID: Action, Name: "Action"},
+		{ID: Comedy, Name: "Comedy"},
+		{ID: Drama, Name: "Drama"},
+		{ID: Horror, Name: "Horror"},
+		{ID: Thriller, Name: "Thriller"},
+	}
+}
+
+type CreateGenreParams struct {
+	ID   Genre  `json:"id"`
+	Name string `json:"name"`
+}
+
+func (p CreateGenreParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if !p.ID.IsValid() {
+		errs["id"] = "id should be 2 to 30 lower case letters, digits or dashes"
+	}
+
+	if len(strings.TrimSpace(p.Name)) == 0 || len(p.Name) > maxGenreNameLen {
+		errs["name"] = fmt.Sprintf("name should be between 1 and %d characters", maxGenreNameLen)
+	}
+
+	return errs
+}
+
+type UpdateGenreParams struct {
+	Name string `json:"name"`
+}
+
+func (p UpdateGenreParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(strings.TrimSpace(p.Name)) == 0 || len(p.Name) > maxGenreNameLen {
+		errs["name"] = fmt.Sprintf("name should be between 1 and %d characters", maxGenreNameLen)
+	}
+
+	return errs
+}
+
+func validateGenres(genres []Genre, errs map[string]string) {
+	seen := map[Genre]bool{}
+	for _, genre := range genres {
+		if !genre.IsValid() {
+			errs["genres"] = fmt.Sprintf("invalid genre %q", genre)
+			return
+		}
+		if seen[genre] {
+			errs["genres"] = fmt.Sprintf("duplicate genre %q", genre)
+			return
+		}
+		seen[genre] = true
+	}
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Address struct {
+	Street     string `bson:"street" json:"street"`
+	PostalCode string `bson:"postalCode" json:"postalCode"`
+	City       string `bson:"city" json:"city"`
+}
+
+func (a Address) validate(errs map[string]string) {
+	if len(strings.TrimSpace(a.Street)) == 0 {
+		errs["address.street"] = "street is required"
+	}
+
+	if len(strings.TrimSpace(a.City)) == 0 {
+		errs["address.city"] = "city is required"
+	}
+}
+
+func (a Address) String() string {
+	city := strings.TrimSpace(a.PostalCode + " " + a.City)
+	return fmt.Sprintf("%s, %s", a.Street, city)
+}
+
+// Coordinates is how clients send positions, GeoPoint how they are stored.
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+func (c Coordinates) IsValid() bool {
+	return c.Lat >= -90 && c.Lat <= 90 && c.Lng >= -180 && c.Lng <= 180
+}
+
+// GeoPoint is a GeoJSON point. Note that GeoJSON puts the longitude first.
+type GeoPoint struct {
+	Type        string     `bson:"type" json:"type"`
+	Coordinates [2]float64 `bson:"coordinates" json:"coordinates"`
+}
+
+func NewGeoPoint(c Coordinates) *GeoPoint {
+	return &GeoPoint{
+		Type:        "Point",
+		Coordinates: [2]float64{c.Lng, c.Lat},
+	}
+}
+
+// NearbyCinema is a cinema found by a search around a position, with its
+// distance from that position in meters.
+type NearbyCinema struct {
+	Cinema   `bson:",inline"`
+	Distance float64 `bson:"distance" json:"distance"`
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"crypto/rand"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	giftCardCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	giftCardCodeGroups   = 4
+	giftCardCodeGroupLen = 4
+	maxGiftCardAmount    = 1000
+)
+
+type GiftCard struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Code           string             `bson:"code" json:"code"`
+	InitialBalance float64            `bson:"initialBalance" json:"initialBalance"`
+	Balance        float64            `bson:"balance" json:"balance"`
+	Currency       string             `bson:"currency" json:"currency"`
+	IssuedBy       primitive.ObjectID `bson:"issuedBy" json:"-"`
+	IssuedAt       time.Time          `bson:"issuedAt" json:"issuedAt"`
+	ExpiresAt      time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	Disabled       bool               `bson:"disabled" json:"disabled"`
+}
+
+func (g *GiftCard) IsRedeemable(now time.Time) bool {
+	if g.Disabled || g.Balance <= 0 {
+		return false
+	}
+
+	return g.ExpiresAt.IsZero() || now.Before(g.ExpiresAt)
+}
+
+type CreateGiftCardParams struct {
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (p CreateGiftCardParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.Amount <= 0 || p.Amount > maxGiftCardAmount {
+		errs["amount"] = fmt.Sprintf("amount should be between 0 and %d", maxGiftCardAmount)
+	}
+
+	if len(p.Currency) > 0 && len(p.Currency) != 3 {
+		errs["currency"] = "currency should be a three letter ISO code"
+	}
+
+	if !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(time.Now()) {
+		errs["expiresAt"] = "expiresAt should be in the future"
+	}
+
+	return errs
+}
+
+func NewGiftCardFromParams(params CreateGiftCardParams, issuer *User) (*GiftCard, error) {
+	code, err := newGiftCardCode()
+	if err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(params.Currency)
+	if len(currency) == 0 {
+		currency = DefaultCurrency
+	}
+
+	amount := RoundMoney(params.Amount)
+
+	return &GiftCard{
+		Code:           code,
+		InitialBalance: amount,
+		Balance:        amount,
+		Currency:       currency,
+		IssuedBy:       issuer.ID,
+		IssuedAt:       time.Now().UTC(),
+		ExpiresAt:      params.ExpiresAt,
+	}, nil
+}
+
+// NormalizeGiftCardCode lets customers type codes in lower case and with
+// surrounding whitespace.
+func NormalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func newGiftCardCode() (string, error) {
+	groups := make([]string, giftCardCodeGroups)
+	alphabetLen := big.NewInt(int64(len(giftCardCodeAlphabet)))
+
+	for i := range groups {
+		group := make([]byte, giftCardCodeGroupLen)
+		for j := range group {
+			n, err := rand.Int(rand.Reader, alphabetLen)
+			if err != nil {
+				return "", err
+			}
+			group[j] = giftCardCodeAlphabet[n.Int64()]
+		}
+		groups[i] = string(group)
+	}
+
+	return strings.Join(groups, "-"), nil
+}
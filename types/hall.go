@@ -0,0 +1,188 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxHallCapacity = 1000
+	maxSurcharge    = 50
+)
+
+// HallFeature is a screen format or sound system customers choose a
+// screening for.
+type HallFeature string
+
+const (
+	FeatureIMAX       HallFeature = "imax"
+	Feature3D         HallFeature = "3d"
+	FeatureDolbyAtmos HallFeature = "dolby-atmos"
+	Feature4DX        HallFeature = "4dx"
+)
+
+// hallFeatures are the known features and how they are printed on tickets.
+var hallFeatures = map[HallFeature]string{
+	FeatureIMAX:       "IMAX",
+	Feature3D:         "3D",
+	FeatureDolbyAtmos: "Dolby Atmos",
+	Feature4DX:        "4DX",
+}
+
+func (f HallFeature) IsValid() bool {
+	_, ok := hallFeatures[f]
+	return ok
+}
+
+func (f HallFeature) Name() string {
+	return hallFeatures[f]
+}
+
+// validateFeatures also rejects repeated features, which would add their
+// surcharge twice.
+func validateFeatures(features []HallFeature, errs map[string]string) {
+	seen := map[HallFeature]bool{}
+	for _, feature := range features {
+		if !feature.IsValid() {
+			errs["features"] = fmt.Sprintf("unknown feature %q", feature)
+			return
+		}
+
+		if seen[feature] {
+			errs["features"] = fmt.Sprintf("feature %q is listed twice", feature)
+			return
+		}
+		seen[feature] = true
+	}
+}
+
+// validateSurcharges checks the surcharges a cinema charges per ticket for
+// halls with a feature.
+func validateSurcharges(surcharges map[HallFeature]float64, errs map[string]string) {
+	for feature, amount := range surcharges {
+		if !feature.IsValid() {
+			errs["surcharges"] = fmt.Sprintf("unknown feature %q", feature)
+			return
+		}
+
+		if amount < 0 || amount > maxSurcharge {
+			errs["surcharges"] = fmt.Sprintf("surcharges should be between 0 and %d", maxSurcharge)
+			return
+		}
+	}
+}
+
+type CreateHallParams struct {
+	Capacity int           `json:"capacity"`
+	Price    float64       `json:"price"`
+	Movie    string        `json:"movie"`
+	Cinema   string        `json:"cinema"`
+	Features []HallFeature `json:"features"`
+}
+
+func (p CreateHallParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.Capacity < 1 || p.Capacity > maxHallCapacity {
+		errs["capacity"] = fmt.Sprintf("capacity should be between 1 and %d", maxHallCapacity)
+	}
+
+	if p.Price <= 0 {
+		errs["price"] = "price should be greater than 0"
+	}
+
+	if !primitive.IsValidObjectID(p.Movie) {
+		errs["movie"] = "invalid movie id"
+	}
+
+	if !primitive.IsValidObjectID(p.Cinema) {
+		errs["cinema"] = "invalid cinema id"
+	}
+
+	validateFeatures(p.Features, errs)
+
+	return errs
+}
+
+func NewHallFromParams(params CreateHallParams) *Hall {
+	movieID, _ := primitive.ObjectIDFromHex(params.Movie)
+	cinemaID, _ := primitive.ObjectIDFromHex(params.Cinema)
+
+	return &Hall{
+		Capacity: params.Capacity,
+		Price:    RoundMoney(params.Price),
+		Movie:    movieID,
+		Cinema:   cinemaID,
+		Features: params.Features,
+	}
+}
+
+type UpdateHallParams struct {
+	Capacity *int          `json:"capacity"`
+	Price    *float64      `json:"price"`
+	Movie    string        `json:"movie"`
+	Cinema   string        `json:"cinema"`
+	Features []HallFeature `json:"features"`
+}
+
+func (p UpdateHallParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.Capacity != nil && (*p.Capacity < 1 || *p.Capacity > maxHallCapacity) {
+		errs["capacity"] = fmt.Sprintf("capacity should be between 1 and %d", maxHallCapacity)
+	}
+
+	if p.Price != nil && *p.Price <= 0 {
+		errs["price"] = "price should be greater than 0"
+	}
+
+	if len(p.Movie) > 0 && !primitive.IsValidObjectID(p.Movie) {
+		errs["movie"] = "invalid movie id"
+	}
+
+	if len(p.Cinema) > 0 && !primitive.IsValidObjectID(p.Cinema) {
+		errs["cinema"] = "invalid cinema id"
+	}
+
+	validateFeatures(p.Features, errs)
+
+	return errs
+}
+
+func (p UpdateHallParams) ToBSON() bson.M {
+	m := bson.M{}
+
+	if p.Capacity != nil {
+		m["capacity"] = *p.Capacity
+	}
+
+	if p.Price != nil {
+		m["price"] = RoundMoney(*p.Price)
+	}
+
+	if movieID, err := primitive.ObjectIDFromHex(p.Movie); err == nil {
+		m["movie"] = movieID
+	}
+
+	if cinemaID, err := primitive.ObjectIDFromHex(p.Cinema); err == nil {
+		m["cinema"] = cinemaID
+	}
+
+	if p.Features != nil {
+		m["features"] = p.Features
+	}
+
+	return m
+}
+
+type Hall struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ExternalID     string             `bson:"externalID,omitempty" json:"externalID,omitempty"`
+	Capacity       int                `bson:"capacity" json:"capacity"`
+	Price          float64            `bson:"price" json:"price"`
+	Movie          primitive.ObjectID `bson:"movie" json:"movie"`
+	Cinema         primitive.ObjectID `bson:"cinema" json:"cinema"`
+	Features       []HallFeature      `bson:"features,omitempty" json:"features,omitempty"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+}
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type InvoiceParty struct {
+	Name    string `bson:"name" json:"name"`
+	Address string `bson:"address,omitempty" json:"address,omitempty"`
+	Country string `bson:"country,omitempty" json:"country,omitempty"`
+	Email   string `bson:"email,omitempty" json:"email,omitempty"`
+}
+
+type Invoice struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Number    string             `bson:"number" json:"number"`
+	BookingID primitive.ObjectID `bson:"bookingID" json:"bookingID"`
+	UserID    primitive.ObjectID `bson:"userID" json:"userID"`
+	CinemaID  primitive.ObjectID `bson:"cinemaID" json:"cinemaID"`
+	IssuedAt  time.Time          `bson:"issuedAt" json:"issuedAt"`
+	Seller    InvoiceParty       `bson:"seller" json:"seller"`
+	Customer  InvoiceParty       `bson:"customer" json:"customer"`
+	Price     PriceBreakdown     `bson:"price" json:"price"`
+}
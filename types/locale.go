@@ -0,0 +1,130 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Locale string
+
+const (
+	LocaleEnglish Locale = "en"
+	LocaleGerman  Locale = "de"
+	LocaleTurkish Locale = "tr"
+
+	// DefaultLocale is the language of the titles, synopses and descriptions
+	// stored on the documents themselves. Other locales are translations.
+	DefaultLocale = LocaleEnglish
+)
+
+var locales = map[Locale]bool{
+	LocaleEnglish: true,
+	LocaleGerman:  true,
+	LocaleTurkish: true,
+}
+
+func (l Locale) IsValid() bool {
+	return locales[l]
+}
+
+// ParseLocale returns the supported locale of a language tag like "de" or
+// "de-DE", ignoring the region.
+func ParseLocale(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i != -1 {
+		tag = tag[:i]
+	}
+
+	locale := Locale(tag)
+
+	return locale, locale.IsValid()
+}
+
+type MovieTranslation struct {
+	Title    string `bson:"title,omitempty" json:"title,omitempty"`
+	Synopsis string `bson:"synopsis,omitempty" json:"synopsis,omitempty"`
+}
+
+type CinemaTranslation struct {
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// validateLocales checks the locales of translations. The default locale is
+// not a translation, its content belongs on the document itself.
+func validateLocales[T any](translations map[Locale]T, errs map[string]string) bool {
+	for locale := range translations {
+		if !locale.IsValid() {
+			errs["translations"] = fmt.Sprintf("unsupported locale %q", locale)
+			return false
+		}
+
+		if locale == DefaultLocale {
+			errs["translations"] = fmt.Sprintf("%q is the default locale and can not be translated to", locale)
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateMovieTranslations(translations map[Locale]MovieTranslation, errs map[string]string) {
+	if !validateLocales(translations, errs) {
+		return
+	}
+
+	for locale, t := range translations {
+		if len(t.Title) > maxTitleLen {
+			errs["translations"] = fmt.Sprintf("%s title should be at most %d characters", locale, maxTitleLen)
+		}
+
+		if len(t.Synopsis) > maxSynopsisLen {
+			errs["translations"] = fmt.Sprintf("%s synopsis should be at most %d characters", locale, maxSynopsisLen)
+		}
+	}
+}
+
+func validateCinemaTranslations(translations map[Locale]CinemaTranslation, errs map[string]string) {
+	if !validateLocales(translations, errs) {
+		return
+	}
+
+	for locale, t := range translations {
+		if len(t.Description) > maxDescriptionLen {
+			errs["translations"] = fmt.Sprintf("%s description should be at most %d characters", locale, maxDescriptionLen)
+		}
+	}
+}
+
+// translationsToBSON sets each translation on its own, so that updates
+// replace the given locales and keep the others.
+func translationsToBSON[T any](translations map[Locale]T, b map[string]any) {
+	for locale, t := range translations {
+		b["translations."+string(locale)] = t
+	}
+}
+
+// Localize replaces the title and synopsis with their translation to the
+// locale where there is one, and drops the translations from the movie.
+func (m *Movie) Localize(locale Locale) {
+	if t, ok := m.Translations[locale]; ok {
+		if len(t.Title) > 0 {
+			m.Title = t.Title
+		}
+
+		if len(t.Synopsis) > 0 {
+			m.Synopsis = t.Synopsis
+		}
+	}
+
+	m.Translations = nil
+}
+
+// Localize replaces the description with its translation to the locale
+// where there is one, and drops the translations from the cinema.
+func (c *Cinema) Localize(locale Locale) {
+	if t, ok := c.Translations[locale]; ok && len(t.Description) > 0 {
+		c.Description = t.Description
+	}
+
+	c.Translations = nil
+}
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"time"
+)
+
+type LoyaltyEntryKind string
+
+const (
+	LoyaltyEarn     LoyaltyEntryKind = "earn"
+	LoyaltyBonus    LoyaltyEntryKind = "bonus"
+	LoyaltyRedeem   LoyaltyEntryKind = "redeem"
+	LoyaltyReversal LoyaltyEntryKind = "reversal"
+)
+
+// LoyaltyEntry is a single movement on a user's points ledger. Earned points
+// are positive and redeemed points negative.
+type LoyaltyEntry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID      primitive.ObjectID `bson:"userID" json:"userID"`
+	Kind        LoyaltyEntryKind   `bson:"kind" json:"kind"`
+	Points      int                `bson:"points" json:"points"`
+	BookingID   primitive.ObjectID `bson:"bookingID,omitempty" json:"bookingID,omitempty"`
+	Description string             `bson:"description" json:"description"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type SessionBonus struct {
+	Session Session `bson:"session" json:"session"`
+	Points  int     `bson:"points" json:"points"`
+}
+
+type LoyaltyProgram struct {
+	// PointsPerUnit is the number of points earned per unit of currency paid.
+	PointsPerUnit  float64        `bson:"pointsPerUnit" json:"pointsPerUnit"`
+	SessionBonuses []SessionBonus `bson:"sessionBonuses" json:"sessionBonuses"`
+	// TicketCost is the number of points a ticket can be redeemed for.
+	TicketCost int `bson:"ticketCost" json:"ticketCost"`
+}
+
+func DefaultLoyaltyProgram() *LoyaltyProgram {
+	return &LoyaltyProgram{
+		PointsPerUnit:  1,
+		SessionBonuses: []SessionBonus{},
+		TicketCost:     100,
+	}
+}
+
+func (p *LoyaltyProgram) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.PointsPerUnit < 0 {
+		errs["pointsPerUnit"] = "pointsPerUnit should not be negative"
+	}
+
+	if p.TicketCost <= 0 {
+		errs["ticketCost"] = "ticketCost should be positive"
+	}
+
+	for _, bonus := range p.SessionBonuses {
+		if bonus.Session < Morning || bonus.Session > Night {
+			errs["sessionBonuses"] = fmt.Sprintf("invalid session %d", bonus.Session)
+		}
+		if bonus.Points < 0 {
+			errs["sessionBonuses"] = "bonus points should not be negative"
+		}
+	}
+
+	return errs
+}
+
+// EarnedPoints returns the points for the amount paid and the bonus for the
+// booked session.
+func (p *LoyaltyProgram) EarnedPoints(session Session, amountPaid float64) (points int, bonus int) {
+	points = int(math.Floor(amountPaid * p.PointsPerUnit))
+	for _, b := range p.SessionBonuses {
+		if b.Session == session {
+			bonus += b.Points
+		}
+	}
+
+	return points, bonus
+}
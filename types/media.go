@@ -0,0 +1,19 @@
+package types
+
+import (
+	"time"
+)
+
+// Image is an uploaded picture and a thumbnail of it. The keys locate both
+// files in blob storage.
+type Image struct {
+	ID           string    `bson:"id" json:"id"`
+	URL          string    `bson:"url" json:"url"`
+	ThumbnailURL string    `bson:"thumbnailURL" json:"thumbnailURL"`
+	Key          string    `bson:"key" json:"-"`
+	ThumbnailKey string    `bson:"thumbnailKey" json:"-"`
+	ContentType  string    `bson:"contentType" json:"contentType"`
+	Width        int       `bson:"width" json:"width"`
+	Height       int       `bson:"height" json:"height"`
+	UploadedAt   time.Time `bson:"uploadedAt" json:"uploadedAt"`
+}
@@ -0,0 +1,270 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	maxTitleLen         = 200
+	maxSynopsisLen      = 2000
+	maxRuntime          = 600
+	maxCertificationLen = 20
+)
+
+// MovieMetadata is everything the listings show about a movie besides its
+// title and genre.
+type MovieMetadata struct {
+	// Runtime is the length of the movie in minutes.
+	Runtime           int       `bson:"runtime,omitempty" json:"runtime,omitempty"`
+	ReleaseDate       time.Time `bson:"releaseDate,omitempty" json:"releaseDate,omitempty"`
+	Synopsis          string    `bson:"synopsis,omitempty" json:"synopsis,omitempty"`
+	Director          string    `bson:"director,omitempty" json:"director,omitempty"`
+	Cast              []string  `bson:"cast,omitempty" json:"cast,omitempty"`
+	OriginalLanguage  string    `bson:"originalLanguage,omitempty" json:"originalLanguage,omitempty"`
+	AudioLanguages    []string  `bson:"audioLanguages,omitempty" json:"audioLanguages,omitempty"`
+	SubtitleLanguages []string  `bson:"subtitleLanguages,omitempty" json:"subtitleLanguages,omitempty"`
+	PosterURL         string    `bson:"posterURL,omitempty" json:"posterURL,omitempty"`
+	TrailerURL        string    `bson:"trailerURL,omitempty" json:"trailerURL,omitempty"`
+	// Certification is the age rating as printed on the poster, e.g. "PG-13"
+	// or "FSK 12".
+	Certification string `bson:"certification,omitempty" json:"certification,omitempty"`
+}
+
+func (m MovieMetadata) validate(errs map[string]string) {
+	if m.Runtime < 0 || m.Runtime > maxRuntime {
+		errs["runtime"] = fmt.Sprintf("runtime should be between 1 and %d minutes", maxRuntime)
+	}
+
+	if len(m.Synopsis) > maxSynopsisLen {
+		errs["synopsis"] = fmt.Sprintf("synopsis should be at most %d characters", maxSynopsisLen)
+	}
+
+	for _, name := range m.Cast {
+		if len(strings.TrimSpace(name)) == 0 {
+			errs["cast"] = "cast members should not be empty"
+		}
+	}
+
+	if len(m.OriginalLanguage) > 0 && !isLanguageCode(m.OriginalLanguage) {
+		errs["originalLanguage"] = "originalLanguage should be a two letter ISO code"
+	}
+
+	for _, lang := range m.AudioLanguages {
+		if !isLanguageCode(lang) {
+			errs["audioLanguages"] = "audioLanguages should be two letter ISO codes"
+		}
+	}
+
+	for _, lang := range m.SubtitleLanguages {
+		if !isLanguageCode(lang) {
+			errs["subtitleLanguages"] = "subtitleLanguages should be two letter ISO codes"
+		}
+	}
+
+	if len(m.PosterURL) > 0 && !isWebURL(m.PosterURL) {
+		errs["posterURL"] = "posterURL should be an http(s) URL"
+	}
+
+	if len(m.TrailerURL) > 0 && !isWebURL(m.TrailerURL) {
+		errs["trailerURL"] = "trailerURL should be an http(s) URL"
+	}
+
+	if len(m.Certification) > maxCertificationLen {
+		errs["certification"] = fmt.Sprintf("certification should be at most %d characters", maxCertificationLen)
+	}
+}
+
+func (m MovieMetadata) normalize() MovieMetadata {
+	m.OriginalLanguage = strings.ToLower(m.OriginalLanguage)
+	m.AudioLanguages = lowerAll(m.AudioLanguages)
+	m.SubtitleLanguages = lowerAll(m.SubtitleLanguages)
+
+	return m
+}
+
+// toBSON returns the fields that are set, for partial updates.
+func (m MovieMetadata) toBSON(b bson.M) {
+	m = m.normalize()
+
+	if m.Runtime > 0 {
+		b["runtime"] = m.Runtime
+	}
+
+	if !m.ReleaseDate.IsZero() {
+		b["releaseDate"] = m.ReleaseDate
+	}
+
+	if len(m.Synopsis) > 0 {
+		b["synopsis"] = m.Synopsis
+	}
+
+	if len(m.Director) > 0 {
+		b["director"] = m.Director
+	}
+
+	if m.Cast != nil {
+		b["cast"] = m.Cast
+	}
+
+	if len(m.OriginalLanguage) > 0 {
+		b["originalLanguage"] = m.OriginalLanguage
+	}
+
+	if m.AudioLanguages != nil {
+		b["audioLanguages"] = m.AudioLanguages
+	}
+
+	if m.SubtitleLanguages != nil {
+		b["subtitleLanguages"] = m.SubtitleLanguages
+	}
+
+	if len(m.PosterURL) > 0 {
+		b["posterURL"] = m.PosterURL
+	}
+
+	if len(m.TrailerURL) > 0 {
+		b["trailerURL"] = m.TrailerURL
+	}
+
+	if len(m.Certification) > 0 {
+		b["certification"] = m.Certification
+	}
+}
+
+type CreateMovieParams struct {
+	Title        string                      `json:"title"`
+	Genres       []Genre                     `json:"genres"`
+	Translations map[Locale]MovieTranslation `json:"translations"`
+	MovieMetadata
+}
+
+func (p CreateMovieParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(p.Title) == 0 || len(p.Title) > maxTitleLen {
+		errs["title"] = fmt.Sprintf("title should be between 1 and %d characters", maxTitleLen)
+	}
+
+	if len(p.Genres) == 0 {
+		errs["genres"] = "at least one genre is required"
+	} else {
+		validateGenres(p.Genres, errs)
+	}
+
+	p.MovieMetadata.validate(errs)
+	validateMovieTranslations(p.Translations, errs)
+
+	return errs
+}
+
+func NewMovieFromParams(params CreateMovieParams) *Movie {
+	return &Movie{
+		Title:         params.Title,
+		Genres:        params.Genres,
+		MovieMetadata: params.MovieMetadata.normalize(),
+		Translations:  params.Translations,
+	}
+}
+
+type UpdateMovieParams struct {
+	Title  string  `json:"title"`
+	Genres []Genre `json:"genres"`
+	// Translations replace the translations to the given locales only.
+	Translations map[Locale]MovieTranslation `json:"translations"`
+	MovieMetadata
+}
+
+func (p UpdateMovieParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(p.Title) > maxTitleLen {
+		errs["title"] = fmt.Sprintf("title should be between 1 and %d characters", maxTitleLen)
+	}
+
+	if p.Genres != nil {
+		if len(p.Genres) == 0 {
+			errs["genres"] = "at least one genre is required"
+		} else {
+			validateGenres(p.Genres, errs)
+		}
+	}
+
+	p.MovieMetadata.validate(errs)
+	validateMovieTranslations(p.Translations, errs)
+
+	return errs
+}
+
+func (p UpdateMovieParams) ToBSON() bson.M {
+	m := bson.M{}
+
+	if len(p.Title) > 0 {
+		m["title"] = p.Title
+	}
+
+	if p.Genres != nil {
+		m["genres"] = p.Genres
+	}
+
+	p.MovieMetadata.toBSON(m)
+	translationsToBSON(p.Translations, m)
+
+	return m
+}
+
+type Movie struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ExternalID    string             `bson:"externalID,omitempty" json:"externalID,omitempty"`
+	Title         string             `bson:"title" json:"title"`
+	Genres        []Genre            `bson:"genres" json:"genres"`
+	MovieMetadata `bson:",inline"`
+	// Translations hold the title and synopsis in other locales than the
+	// default one.
+	Translations map[Locale]MovieTranslation `bson:"translations,omitempty" json:"translations,omitempty"`
+	// Poster is the uploaded poster, its URL is also the posterURL.
+	Poster *Image  `bson:"poster,omitempty" json:"poster,omitempty"`
+	Stills []Image `bson:"stills,omitempty" json:"stills,omitempty"`
+	// Rating is computed from the visible reviews of the movie.
+	Rating RatingSummary `bson:"rating" json:"rating"`
+}
+
+func isLanguageCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+
+	for _, r := range strings.ToLower(code) {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+func lowerAll(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+
+	return lowered
+}
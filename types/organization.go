@@ -0,0 +1,48 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+const (
+	minOrganizationNameLen = 2
+	maxOrganizationNameLen = 100
+)
+
+// Organization is a cinema chain operating its cinemas on the platform. Its
+// cinemas and everything in them, from halls and showtimes to bookings, are
+// only visible to its own admins.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string             `bson:"name" json:"name"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type CreateOrganizationParams struct {
+	Name string `json:"name"`
+}
+
+func (p CreateOrganizationParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	name := strings.TrimSpace(p.Name)
+	if len(name) < minOrganizationNameLen || len(name) > maxOrganizationNameLen {
+		errs["name"] = fmt.Sprintf("name should be between %d and %d characters", minOrganizationNameLen, maxOrganizationNameLen)
+	}
+
+	return errs
+}
+
+func NewOrganizationFromParams(params CreateOrganizationParams) *Organization {
+	return &Organization{
+		Name:      strings.TrimSpace(params.Name),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+type AddOrganizationAdminParams struct {
+	UserID string `json:"userID"`
+}
@@ -0,0 +1,58 @@
+package types
+
+import "strings"
+
+type PaymentMethod string
+
+const (
+	PaymentGiftCard PaymentMethod = "giftCard"
+	PaymentWallet   PaymentMethod = "wallet"
+	PaymentLoyalty  PaymentMethod = "loyalty"
+	PaymentPass     PaymentMethod = "pass"
+	// PaymentGateway is the part of a booking left to the external payment
+	// gateway once stored value has been applied.
+	PaymentGateway PaymentMethod = "gateway"
+)
+
+// PaymentStatus tells whether the money of a payment has been collected.
+// Stored value is captured when the booking is made, gateway payments stay
+// pending until the gateway reports the capture.
+type PaymentStatus string
+
+const (
+	PaymentPending  PaymentStatus = "pending"
+	PaymentCaptured PaymentStatus = "captured"
+)
+
+type Payment struct {
+	Method    PaymentMethod `bson:"method" json:"method"`
+	Amount    float64       `bson:"amount" json:"amount"`
+	Status    PaymentStatus `bson:"status,omitempty" json:"status,omitempty"`
+	Reference string        `bson:"reference,omitempty" json:"reference,omitempty"`
+}
+
+// IsCaptured reports whether the money of the payment has been collected.
+// Payments recorded before statuses existed count as captured unless they
+// were left to the gateway.
+func (p Payment) IsCaptured() bool {
+	if len(p.Status) == 0 {
+		return p.Method != PaymentGateway
+	}
+
+	return p.Status == PaymentCaptured
+}
+
+// CapturePaymentParams is reported once the gateway collected a payment.
+type CapturePaymentParams struct {
+	Reference string `json:"reference"`
+}
+
+func (p CapturePaymentParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(strings.TrimSpace(p.Reference)) == 0 {
+		errs["reference"] = "reference of the gateway transaction is required"
+	}
+
+	return errs
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	minStars        = 1
+	maxStars        = 5
+	maxReviewLength = 2000
+)
+
+// RatingSummary is the average star rating of everything rated by customers
+// and the number of ratings it is based on. The sum of all stars is kept so
+// that ratings can be added without recomputing the average from scratch.
+type RatingSummary struct {
+	Average float64 `bson:"average" json:"average"`
+	Count   int     `bson:"count" json:"count"`
+	Sum     int     `bson:"sum" json:"-"`
+}
+
+func NewRatingSummary(sum, count int) RatingSummary {
+	if count == 0 {
+		return RatingSummary{}
+	}
+
+	return RatingSummary{
+		Average: math.Round(float64(sum)/float64(count)*10) / 10,
+		Count:   count,
+		Sum:     sum,
+	}
+}
+
+// Review is a star rating and review of a movie by a customer who has seen
+// it. Hidden reviews are moderated away and do not count towards the rating.
+type Review struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	MovieID   primitive.ObjectID `bson:"movieID" json:"movieID"`
+	UserID    primitive.ObjectID `bson:"userID" json:"userID"`
+	BookingID primitive.ObjectID `bson:"bookingID" json:"-"`
+	Author    string             `bson:"author" json:"author"`
+	Stars     int                `bson:"stars" json:"stars"`
+	Text      string             `bson:"text,omitempty" json:"text,omitempty"`
+	Hidden    bool               `bson:"hidden" json:"hidden"`
+	HiddenBy  primitive.ObjectID `bson:"hiddenBy,omitempty" json:"-"`
+	HiddenAt  time.Time          `bson:"hiddenAt,omitempty" json:"hiddenAt,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type CreateReviewParams struct {
+	Stars int    `json:"stars"`
+	Text  string `json:"text"`
+}
+
+func (p CreateReviewParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if p.Stars < minStars || p.Stars > maxStars {
+		errs["stars"] = fmt.Sprintf("stars should be between %d and %d", minStars, maxStars)
+	}
+
+	if len(p.Text) > maxReviewLength {
+		errs["text"] = fmt.Sprintf("text should be at most %d characters", maxReviewLength)
+	}
+
+	return errs
+}
+
+func NewReviewFromParams(params CreateReviewParams, movie *Movie, user *User, booking *Booking) *Review {
+	return &Review{
+		MovieID:   movie.ID,
+		UserID:    user.ID,
+		BookingID: booking.ID,
+		Author:    authorName(user),
+		Stars:     params.Stars,
+		Text:      strings.TrimSpace(params.Text),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// authorName shows reviewers by first name and the initial of their last
+// name.
+func authorName(user *User) string {
+	if len(user.LastName) == 0 {
+		return user.FirstName
+	}
+
+	initial, _ := utf8.DecodeRuneInString(user.LastName)
+
+	return fmt.Sprintf("%s %c.", user.FirstName, unicode.ToUpper(initial))
+}
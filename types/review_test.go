@@ -0,0 +1,28 @@
+package types
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAuthorName(t *testing.T) {
+	tests := []struct {
+		user     User
+		expected string
+	}{
+		{User{FirstName: "heron", LastName: "preston"}, "heron P."},
+		{User{FirstName: "Elif", LastName: "Özdemir"}, "Elif Ö."},
+		{User{FirstName: "Emre", LastName: "şahin"}, "Emre Ş."},
+		{User{FirstName: "Cher"}, "Cher"},
+	}
+
+	for _, tt := range tests {
+		name := authorName(&tt.user)
+		if name != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, name)
+		}
+		if !utf8.ValidString(name) {
+			t.Errorf("expected valid UTF-8, got %q", name)
+		}
+	}
+}
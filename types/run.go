@@ -0,0 +1,76 @@
+package types
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// RunWindow is the time a movie is on the programme of a cinema, from the day
+// it opens until the day it closes, both inclusive. Runs without a closing
+// day are open ended.
+type RunWindow struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	MovieID        primitive.ObjectID `bson:"movieID" json:"movieID"`
+	CinemaID       primitive.ObjectID `bson:"cinemaID" json:"cinemaID"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	Opens          time.Time          `bson:"opens" json:"opens"`
+	Closes         time.Time          `bson:"closes,omitempty" json:"closes,omitempty"`
+}
+
+type CreateRunWindowParams struct {
+	MovieID  string `json:"movieID"`
+	CinemaID string `json:"cinemaID"`
+	Opens    string `json:"opens"`
+	Closes   string `json:"closes"`
+}
+
+func (p CreateRunWindowParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if !primitive.IsValidObjectID(p.MovieID) {
+		errs["movieID"] = "invalid movie id"
+	}
+
+	if !primitive.IsValidObjectID(p.CinemaID) {
+		errs["cinemaID"] = "invalid cinema id"
+	}
+
+	opens, err := time.Parse(dateLayout, p.Opens)
+	if err != nil {
+		errs["opens"] = "opens should be a date like 2006-01-02"
+	}
+
+	if len(p.Closes) > 0 {
+		closes, err := time.Parse(dateLayout, p.Closes)
+		if err != nil {
+			errs["closes"] = "closes should be a date like 2006-01-02"
+		} else if closes.Before(opens) {
+			errs["closes"] = "closes should not be before opens"
+		}
+	}
+
+	return errs
+}
+
+func NewRunWindowFromParams(params CreateRunWindowParams) *RunWindow {
+	movieID, _ := primitive.ObjectIDFromHex(params.MovieID)
+	cinemaID, _ := primitive.ObjectIDFromHex(params.CinemaID)
+	opens, _ := time.Parse(dateLayout, params.Opens)
+
+	run := &RunWindow{
+		MovieID:  movieID,
+		CinemaID: cinemaID,
+		Opens:    opens,
+	}
+	if closes, err := time.Parse(dateLayout, params.Closes); err == nil {
+		run.Closes = closes
+	}
+
+	return run
+}
+
+// UpcomingMovie is a movie that is coming soon, with the day it opens.
+type UpcomingMovie struct {
+	Movie `bson:",inline"`
+	Opens time.Time `json:"opens"`
+}
@@ -0,0 +1,11 @@
+package types
+
+type MovieHit struct {
+	Movie *Movie  `json:"movie"`
+	Score float64 `json:"score"`
+}
+
+type CinemaHit struct {
+	Cinema *Cinema `json:"cinema"`
+	Score  float64 `json:"score"`
+}
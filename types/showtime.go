@@ -0,0 +1,220 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// DefaultCleaningBuffer is the time in minutes a hall needs between two
+	// showtimes unless a schedule says otherwise.
+	DefaultCleaningBuffer = 15
+	maxCleaningBuffer     = 120
+	maxScheduleDays       = 120
+)
+
+// Schedule is a recurring plan to show a movie in a hall at the same times
+// on some days of the week. It is expanded into showtimes when it is created.
+type Schedule struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	MovieID        primitive.ObjectID `bson:"movieID" json:"movieID"`
+	HallID         primitive.ObjectID `bson:"hallID" json:"hallID"`
+	CinemaID       primitive.ObjectID `bson:"cinemaID" json:"cinemaID"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	Days           []string           `bson:"days" json:"days"`
+	StartTimes     []string           `bson:"startTimes" json:"startTimes"`
+	From           string             `bson:"from" json:"from"`
+	Until          string             `bson:"until" json:"until"`
+	Timezone       string             `bson:"timezone" json:"timezone"`
+	// CleaningBuffer is the time in minutes the hall is blocked after each
+	// showtime.
+	CleaningBuffer int                `bson:"cleaningBuffer" json:"cleaningBuffer"`
+	CreatedBy      primitive.ObjectID `bson:"createdBy" json:"-"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type CreateScheduleParams struct {
+	MovieID        string   `json:"movieID"`
+	HallID         string   `json:"hallID"`
+	Days           []string `json:"days"`
+	StartTimes     []string `json:"startTimes"`
+	From           string   `json:"from"`
+	Until          string   `json:"until"`
+	Timezone       string   `json:"timezone"`
+	CleaningBuffer *int     `json:"cleaningBuffer"`
+}
+
+func (p CreateScheduleParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if !primitive.IsValidObjectID(p.MovieID) {
+		errs["movieID"] = "invalid movie id"
+	}
+
+	if !primitive.IsValidObjectID(p.HallID) {
+		errs["hallID"] = "invalid hall id"
+	}
+
+	if len(p.Days) == 0 {
+		errs["days"] = "at least one day is required"
+	}
+	for _, day := range p.Days {
+		if !isWeekday(strings.ToLower(day)) {
+			errs["days"] = fmt.Sprintf("invalid day %q", day)
+		}
+	}
+
+	if len(p.StartTimes) == 0 {
+		errs["startTimes"] = "at least one start time is required"
+	}
+	for _, start := range p.StartTimes {
+		if _, err := time.Parse(clockLayout, start); err != nil {
+			errs["startTimes"] = fmt.Sprintf("invalid start time %q", start)
+		}
+	}
+
+	from, err := time.Parse(dateLayout, p.From)
+	if err != nil {
+		errs["from"] = "from should be a date like 2006-01-02"
+	}
+	until, err := time.Parse(dateLayout, p.Until)
+	if err != nil {
+		errs["until"] = "until should be a date like 2006-01-02"
+	}
+	if !from.IsZero() && !until.IsZero() {
+		if until.Before(from) {
+			errs["until"] = "until should not be before from"
+		} else if until.Sub(from) > maxScheduleDays*24*time.Hour {
+			errs["until"] = fmt.Sprintf("schedules can span at most %d days", maxScheduleDays)
+		}
+	}
+
+	if len(p.Timezone) > 0 {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			errs["timezone"] = fmt.Sprintf("unknown timezone %q", p.Timezone)
+		}
+	}
+
+	if p.CleaningBuffer != nil && (*p.CleaningBuffer < 0 || *p.CleaningBuffer > maxCleaningBuffer) {
+		errs["cleaningBuffer"] = fmt.Sprintf("cleaningBuffer should be between 0 and %d minutes", maxCleaningBuffer)
+	}
+
+	return errs
+}
+
+func NewScheduleFromParams(params CreateScheduleParams, hall *Hall, creator *User) *Schedule {
+	movieID, _ := primitive.ObjectIDFromHex(params.MovieID)
+
+	timezone := params.Timezone
+	if len(timezone) == 0 {
+		timezone = "UTC"
+	}
+
+	buffer := DefaultCleaningBuffer
+	if params.CleaningBuffer != nil {
+		buffer = *params.CleaningBuffer
+	}
+
+	return &Schedule{
+		MovieID:        movieID,
+		HallID:         hall.ID,
+		CinemaID:       hall.Cinema,
+		OrganizationID: hall.OrganizationID,
+		Days:           normalizeDays(params.Days),
+		StartTimes:     params.StartTimes,
+		From:           params.From,
+		Until:          params.Until,
+		Timezone:       timezone,
+		CleaningBuffer: buffer,
+		CreatedBy:      creator.ID,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+// Showtimes expands the schedule into its showtimes, ordered by start. The
+// runtime of the movie is in minutes.
+func (s *Schedule) Showtimes(runtime int) ([]*Showtime, error) {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := time.ParseInLocation(dateLayout, s.From, location)
+	if err != nil {
+		return nil, err
+	}
+	until, err := time.ParseInLocation(dateLayout, s.Until, location)
+	if err != nil {
+		return nil, err
+	}
+
+	days := map[string]bool{}
+	for _, day := range s.Days {
+		days[day] = true
+	}
+
+	showtimes := []*Showtime{}
+	for date := from; !date.After(until); date = date.AddDate(0, 0, 1) {
+		if !days[strings.ToLower(date.Weekday().String())] {
+			continue
+		}
+
+		for _, start := range s.StartTimes {
+			clock, err := time.Parse(clockLayout, start)
+			if err != nil {
+				return nil, err
+			}
+
+			startsAt := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, location).UTC()
+			endsAt := startsAt.Add(time.Duration(runtime) * time.Minute)
+			showtimes = append(showtimes, &Showtime{
+				ScheduleID:     s.ID,
+				MovieID:        s.MovieID,
+				HallID:         s.HallID,
+				CinemaID:       s.CinemaID,
+				OrganizationID: s.OrganizationID,
+				StartsAt:       startsAt,
+				EndsAt:         endsAt,
+				BlockedUntil:   endsAt.Add(time.Duration(s.CleaningBuffer) * time.Minute),
+			})
+		}
+	}
+
+	sort.Slice(showtimes, func(i, j int) bool {
+		return showtimes[i].StartsAt.Before(showtimes[j].StartsAt)
+	})
+
+	return showtimes, nil
+}
+
+// Showtime is a single screening of a movie in a hall.
+type Showtime struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ScheduleID     primitive.ObjectID `bson:"scheduleID,omitempty" json:"scheduleID,omitempty"`
+	MovieID        primitive.ObjectID `bson:"movieID" json:"movieID"`
+	HallID         primitive.ObjectID `bson:"hallID" json:"hallID"`
+	CinemaID       primitive.ObjectID `bson:"cinemaID" json:"cinemaID"`
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+	StartsAt       time.Time          `bson:"startsAt" json:"startsAt"`
+	EndsAt         time.Time          `bson:"endsAt" json:"endsAt"`
+	// BlockedUntil is when the hall is clean again after the showtime.
+	BlockedUntil time.Time `bson:"blockedUntil" json:"blockedUntil"`
+}
+
+// Overlaps reports whether the two showtimes need the hall at the same time.
+func (s *Showtime) Overlaps(other *Showtime) bool {
+	return s.StartsAt.Before(other.BlockedUntil) && other.StartsAt.Before(s.BlockedUntil)
+}
+
+func normalizeDays(days []string) []string {
+	normalized := make([]string, len(days))
+	for i, day := range days {
+		normalized[i] = strings.ToLower(day)
+	}
+
+	return normalized
+}
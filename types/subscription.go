@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type PlanKind string
+
+const (
+	PlanUnlimited PlanKind = "unlimited"
+	PlanTickets   PlanKind = "tickets"
+)
+
+type SubscriptionPlan struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name       string             `bson:"name" json:"name"`
+	Kind       PlanKind           `bson:"kind" json:"kind"`
+	Price      float64            `bson:"price" json:"price"`
+	Currency   string             `bson:"currency" json:"currency"`
+	PeriodDays int                `bson:"periodDays" json:"periodDays"`
+	// Tickets is the number of tickets included in a PlanTickets plan.
+	Tickets int `bson:"tickets,omitempty" json:"tickets,omitempty"`
+	// DailyLimit caps the tickets booked with the pass per day, zero means no limit.
+	DailyLimit int  `bson:"dailyLimit" json:"dailyLimit"`
+	Active     bool `bson:"active" json:"active"`
+}
+
+type CreatePlanParams struct {
+	Name       string   `json:"name"`
+	Kind       PlanKind `json:"kind"`
+	Price      float64  `json:"price"`
+	Currency   string   `json:"currency"`
+	PeriodDays int      `json:"periodDays"`
+	Tickets    int      `json:"tickets"`
+	DailyLimit int      `json:"dailyLimit"`
+}
+
+func (p CreatePlanParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(p.Name) == 0 {
+		errs["name"] = "name is required"
+	}
+
+	switch p.Kind {
+	case PlanUnlimited:
+	case PlanTickets:
+		if p.Tickets <= 0 {
+			errs["tickets"] = "tickets should be positive for ticket plans"
+		}
+	default:
+		errs["kind"] = fmt.Sprintf("kind should be %q or %q", PlanUnlimited, PlanTickets)
+	}
+
+	if p.Price < 0 {
+		errs["price"] = "price should not be negative"
+	}
+
+	if p.PeriodDays <= 0 {
+		errs["periodDays"] = "periodDays should be positive"
+	}
+
+	if p.DailyLimit < 0 {
+		errs["dailyLimit"] = "dailyLimit should not be negative"
+	}
+
+	return errs
+}
+
+func NewPlanFromParams(params CreatePlanParams) *SubscriptionPlan {
+	currency := params.Currency
+	if len(currency) == 0 {
+		currency = DefaultCurrency
+	}
+
+	return &SubscriptionPlan{
+		Name:       params.Name,
+		Kind:       params.Kind,
+		Price:      RoundMoney(params.Price),
+		Currency:   currency,
+		PeriodDays: params.PeriodDays,
+		Tickets:    params.Tickets,
+		DailyLimit: params.DailyLimit,
+		Active:     true,
+	}
+}
+
+type Subscription struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID           primitive.ObjectID `bson:"userID" json:"userID"`
+	PlanID           primitive.ObjectID `bson:"planID" json:"planID"`
+	Kind             PlanKind           `bson:"kind" json:"kind"`
+	StartsAt         time.Time          `bson:"startsAt" json:"startsAt"`
+	EndsAt           time.Time          `bson:"endsAt" json:"endsAt"`
+	TicketsRemaining int                `bson:"ticketsRemaining" json:"ticketsRemaining"`
+	DailyLimit       int                `bson:"dailyLimit" json:"dailyLimit"`
+	Currency         string             `bson:"currency,omitempty" json:"currency,omitempty"`
+	// Payment is the price of the plan left to the payment gateway. The pass
+	// can only be used once it has been captured. Free plans have none.
+	Payment  *Payment `bson:"payment,omitempty" json:"payment,omitempty"`
+	Canceled bool     `bson:"canceled" json:"canceled"`
+	// DailyUses counts the tickets booked with the pass per day, keyed by
+	// UsageDay, so that the daily limit is enforced together with taking a
+	// ticket.
+	DailyUses map[string]int `bson:"dailyUses,omitempty" json:"-"`
+}
+
+// UsageDay returns the key of the day a booking at the given time counts
+// against in DailyUses.
+func UsageDay(date time.Time) string {
+	return date.UTC().Format("2006-01-02")
+}
+
+// NewSubscription starts a period of the plan at the given time. The plan's
+// limits are copied so that later plan changes do not affect running passes.
+// Passes of paid plans start out with a pending payment.
+func NewSubscription(plan *SubscriptionPlan, userID primitive.ObjectID, startsAt time.Time) *Subscription {
+	sub := &Subscription{
+		UserID:           userID,
+		PlanID:           plan.ID,
+		Kind:             plan.Kind,
+		StartsAt:         startsAt,
+		EndsAt:           startsAt.AddDate(0, 0, plan.PeriodDays),
+		TicketsRemaining: plan.Tickets,
+		DailyLimit:       plan.DailyLimit,
+		Currency:         plan.Currency,
+	}
+	if plan.Price > 0 {
+		sub.Payment = &Payment{
+			Method: PaymentGateway,
+			Amount: plan.Price,
+			Status: PaymentPending,
+		}
+	}
+
+	return sub
+}
+
+// IsPaid reports whether the pass can be used, which is once the price of the
+// plan has been collected.
+func (s *Subscription) IsPaid() bool {
+	return s.Payment == nil || s.Payment.Status != PaymentPending
+}
+
+func (s *Subscription) Covers(date time.Time) bool {
+	return !s.Canceled && s.IsPaid() && !date.Before(s.StartsAt) && date.Before(s.EndsAt)
+}
+
+func (s *Subscription) HasTickets() bool {
+	return s.Kind == PlanUnlimited || s.TicketsRemaining > 0
+}
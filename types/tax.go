@@ -0,0 +1,177 @@
+package types
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"strings"
+)
+
+const DefaultCurrency = "EUR"
+
+type ProductType string
+
+const (
+	ProductTicket     ProductType = "ticket"
+	ProductConcession ProductType = "concession"
+)
+
+func (p ProductType) IsValid() bool {
+	switch p {
+	case ProductTicket, ProductConcession:
+		return true
+	}
+
+	return false
+}
+
+// TaxRate is a percentage applied to a product type. A rate bound to a cinema
+// takes precedence over the rate of the cinema's country.
+type TaxRate struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Country     string             `bson:"country,omitempty" json:"country,omitempty"`
+	CinemaID    primitive.ObjectID `bson:"cinemaID,omitempty" json:"cinemaID,omitempty"`
+	ProductType ProductType        `bson:"productType" json:"productType"`
+	Rate        float64            `bson:"rate" json:"rate"`
+}
+
+type CreateTaxRateParams struct {
+	Name        string      `json:"name"`
+	Country     string      `json:"country"`
+	CinemaID    string      `json:"cinemaID"`
+	ProductType ProductType `json:"productType"`
+	Rate        float64     `json:"rate"`
+}
+
+func (p CreateTaxRateParams) Validate() map[string]string {
+	errs := map[string]string{}
+
+	if len(p.Name) == 0 {
+		errs["name"] = "name is required"
+	}
+
+	if len(p.Country) == 0 && len(p.CinemaID) == 0 {
+		errs["country"] = "either country or cinemaID is required"
+	}
+
+	if len(p.Country) > 0 && len(p.Country) != 2 {
+		errs["country"] = "country should be a two letter ISO code"
+	}
+
+	if len(p.CinemaID) > 0 && !primitive.IsValidObjectID(p.CinemaID) {
+		errs["cinemaID"] = "invalid cinemaID"
+	}
+
+	if !p.ProductType.IsValid() {
+		errs["productType"] = fmt.Sprintf("invalid product type %q", p.ProductType)
+	}
+
+	if p.Rate < 0 || p.Rate >= 100 {
+		errs["rate"] = "rate should be a percentage between 0 and 100"
+	}
+
+	return errs
+}
+
+func NewTaxRateFromParams(params CreateTaxRateParams) *TaxRate {
+	cinemaID, _ := primitive.ObjectIDFromHex(params.CinemaID)
+
+	return &TaxRate{
+		Name:        params.Name,
+		Country:     strings.ToUpper(params.Country),
+		CinemaID:    cinemaID,
+		ProductType: params.ProductType,
+		Rate:        params.Rate,
+	}
+}
+
+// LineItem is a single priced position of a booking. Prices are gross, i.e.
+// they already include tax, which is how cinemas advertise them.
+type LineItem struct {
+	ProductType ProductType `bson:"productType" json:"productType"`
+	Description string      `bson:"description" json:"description"`
+	Quantity    int         `bson:"quantity" json:"quantity"`
+	UnitPrice   float64     `bson:"unitPrice" json:"unitPrice"`
+	TaxRate     float64     `bson:"taxRate" json:"taxRate"`
+	Net         float64     `bson:"net" json:"net"`
+	Tax         float64     `bson:"tax" json:"tax"`
+	Gross       float64     `bson:"gross" json:"gross"`
+}
+
+func NewLineItem(productType ProductType, description string, quantity int, unitPrice, taxRate float64) LineItem {
+	gross := RoundMoney(unitPrice * float64(quantity))
+	net := RoundMoney(gross / (1 + taxRate/100))
+
+	return LineItem{
+		ProductType: productType,
+		Description: description,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		TaxRate:     taxRate,
+		Net:         net,
+		Tax:         RoundMoney(gross - net),
+		Gross:       gross,
+	}
+}
+
+// TaxLine sums up all line items sharing the same tax rate, as required on
+// European receipts.
+type TaxLine struct {
+	Rate float64 `bson:"rate" json:"rate"`
+	Net  float64 `bson:"net" json:"net"`
+	Tax  float64 `bson:"tax" json:"tax"`
+}
+
+type PriceBreakdown struct {
+	Currency string     `bson:"currency" json:"currency"`
+	Items    []LineItem `bson:"items" json:"items"`
+	Taxes    []TaxLine  `bson:"taxes" json:"taxes"`
+	Net      float64    `bson:"net" json:"net"`
+	Tax      float64    `bson:"tax" json:"tax"`
+	Gross    float64    `bson:"gross" json:"gross"`
+}
+
+func NewPriceBreakdown(currency string) *PriceBreakdown {
+	if len(currency) == 0 {
+		currency = DefaultCurrency
+	}
+
+	return &PriceBreakdown{
+		Currency: currency,
+		Items:    []LineItem{},
+		Taxes:    []TaxLine{},
+	}
+}
+
+func (p *PriceBreakdown) Add(item LineItem) {
+	p.Items = append(p.Items, item)
+	p.Net = RoundMoney(p.Net + item.Net)
+	p.Tax = RoundMoney(p.Tax + item.Tax)
+	p.Gross = RoundMoney(p.Gross + item.Gross)
+
+	for i := range p.Taxes {
+		if p.Taxes[i].Rate == item.TaxRate {
+			p.Taxes[i].Net = RoundMoney(p.Taxes[i].Net + item.Net)
+			p.Taxes[i].Tax = RoundMoney(p.Taxes[i].Tax + item.Tax)
+			return
+		}
+	}
+	p.Taxes = append(p.Taxes, TaxLine{Rate: item.TaxRate, Net: item.Net, Tax: item.Tax})
+}
+
+// GrossOf sums up the gross amount of all items of the given product type.
+func (p *PriceBreakdown) GrossOf(productType ProductType) float64 {
+	var gross float64
+	for _, item := range p.Items {
+		if item.ProductType == productType {
+			gross += item.Gross
+		}
+	}
+
+	return RoundMoney(gross)
+}
+
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
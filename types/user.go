@@ -6,6 +6,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 	"regexp"
+	"strings"
 )
 
 const (
@@ -37,13 +38,20 @@ func (p CreateUserParams) Validate() map[string]string {
 		errs["password"] = fmt.Sprintf("password length should be at least %d characters", minPasswordLength)
 	}
 
-	if !isEmailValid(p.Email) {
+	if !isEmailValid(NormalizeEmail(p.Email)) {
 		errs["email"] = fmt.Sprintf("invalid email address")
 	}
 
 	return errs
 }
 
+// NormalizeEmail returns the form email addresses are stored and looked up
+// in. Addresses are compared case insensitively, so that a user registered
+// once can not register again with different casing.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func isEmailValid(email string) bool {
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,4}$`)
 
@@ -81,6 +89,16 @@ type User struct {
 	Email             string             `bson:"email" json:"email"`
 	EncryptedPassword string             `bson:"EncryptedPassword" json:"-"`
 	IsAdmin           bool               `bson:"isAdmin" json:"isAdmin"`
+	// OrganizationID is set for the staff of a cinema chain. Admins with an
+	// organization administer its cinemas only, admins without one the
+	// whole platform.
+	OrganizationID primitive.ObjectID `bson:"organizationID,omitempty" json:"organizationID,omitempty"`
+}
+
+// IsPlatformAdmin reports whether the user administers the whole platform,
+// including the movie catalog and everything shared by all organizations.
+func (u *User) IsPlatformAdmin() bool {
+	return u.IsAdmin && u.OrganizationID.IsZero()
 }
 
 func NewUserFromParams(params CreateUserParams) (*User, error) {
@@ -92,7 +110,7 @@ func NewUserFromParams(params CreateUserParams) (*User, error) {
 	return &User{
 		FirstName:         params.FirstName,
 		LastName:          params.LastName,
-		Email:             params.Email,
+		Email:             NormalizeEmail(params.Email),
 		EncryptedPassword: string(encPw),
 	}, nil
 }
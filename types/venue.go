@@ -0,0 +1,143 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const clockLayout = "15:04"
+
+type Amenity string
+
+const (
+	AmenityParking           Amenity = "parking"
+	AmenityWheelchairAccess  Amenity = "wheelchair-access"
+	AmenityAccessibleToilets Amenity = "accessible-toilets"
+	AmenityHearingLoop       Amenity = "hearing-loop"
+	AmenityBar               Amenity = "bar"
+	AmenityRestaurant        Amenity = "restaurant"
+	AmenityBabyChanging      Amenity = "baby-changing"
+)
+
+var amenities = []Amenity{
+	AmenityParking,
+	AmenityWheelchairAccess,
+	AmenityAccessibleToilets,
+	AmenityHearingLoop,
+	AmenityBar,
+	AmenityRestaurant,
+	AmenityBabyChanging,
+}
+
+func (a Amenity) IsValid() bool {
+	for _, amenity := range amenities {
+		if a == amenity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpeningHours are the hours a cinema is open on a weekday, as "15:04" in the
+// cinema's local time. A closing time before the opening time is on the next
+// day. Days without opening hours are closed.
+type OpeningHours struct {
+	Day   string `bson:"day" json:"day"`
+	Open  string `bson:"open" json:"open"`
+	Close string `bson:"close" json:"close"`
+}
+
+type Contact struct {
+	Phone   string `bson:"phone,omitempty" json:"phone,omitempty"`
+	Email   string `bson:"email,omitempty" json:"email,omitempty"`
+	Website string `bson:"website,omitempty" json:"website,omitempty"`
+}
+
+func (c Contact) validate(errs map[string]string) {
+	if len(c.Phone) > 0 && !isPhoneValid(c.Phone) {
+		errs["contact.phone"] = "phone should only contain digits, spaces and +()-/"
+	}
+
+	if len(c.Email) > 0 && !isEmailValid(c.Email) {
+		errs["contact.email"] = "email is invalid"
+	}
+
+	if len(c.Website) > 0 && !isWebURL(c.Website) {
+		errs["contact.website"] = "website should be an http(s) URL"
+	}
+}
+
+func validateOpeningHours(hours []OpeningHours, errs map[string]string) {
+	seen := map[string]bool{}
+	for _, h := range hours {
+		day := strings.ToLower(h.Day)
+		if !isWeekday(day) {
+			errs["openingHours"] = fmt.Sprintf("invalid day %q", h.Day)
+			return
+		}
+		if seen[day] {
+			errs["openingHours"] = fmt.Sprintf("%s is listed twice", day)
+			return
+		}
+		seen[day] = true
+
+		if _, err := time.Parse(clockLayout, h.Open); err != nil {
+			errs["openingHours"] = fmt.Sprintf("invalid opening time %q on %s", h.Open, day)
+			return
+		}
+		if _, err := time.Parse(clockLayout, h.Close); err != nil {
+			errs["openingHours"] = fmt.Sprintf("invalid closing time %q on %s", h.Close, day)
+			return
+		}
+	}
+}
+
+func validateAmenities(tags []Amenity, errs map[string]string) {
+	for _, tag := range tags {
+		if !tag.IsValid() {
+			errs["amenities"] = fmt.Sprintf("unknown amenity %q", tag)
+			return
+		}
+	}
+}
+
+func normalizeOpeningHours(hours []OpeningHours) []OpeningHours {
+	if hours == nil {
+		return nil
+	}
+
+	normalized := make([]OpeningHours, len(hours))
+	for i, h := range hours {
+		h.Day = strings.ToLower(h.Day)
+		normalized[i] = h
+	}
+
+	return normalized
+}
+
+func isWeekday(day string) bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isPhoneValid(phone string) bool {
+	digits := 0
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune(" +()-/", r):
+		default:
+			return false
+		}
+	}
+
+	return digits >= 5
+}
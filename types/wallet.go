@@ -0,0 +1,27 @@
+package types
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type WalletEntryKind string
+
+const (
+	WalletRefund   WalletEntryKind = "refund"
+	WalletPayment  WalletEntryKind = "payment"
+	WalletReversal WalletEntryKind = "reversal"
+)
+
+// WalletEntry is a single movement on a user's stored-value wallet. Credits
+// are positive and debits negative, so the balance is the sum of all entries.
+type WalletEntry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID      primitive.ObjectID `bson:"userID" json:"userID"`
+	Kind        WalletEntryKind    `bson:"kind" json:"kind"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	Currency    string             `bson:"currency" json:"currency"`
+	BookingID   primitive.ObjectID `bson:"bookingID,omitempty" json:"bookingID,omitempty"`
+	Description string             `bson:"description" json:"description"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}